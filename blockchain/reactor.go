@@ -187,6 +187,7 @@ func (bcR *BlockchainReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 	}
 
 	bcR.Logger.Debug("Receive", "src", src, "chID", chID, "msg", msg)
+	p2p.ObserveMessageReceived(src, chID, msg, len(msgBytes))
 
 	switch msg := msg.(type) {
 	case *bcBlockRequestMessage:
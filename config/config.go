@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -15,6 +18,13 @@ const (
 	// FuzzModeDelay is a mode in which we randomly sleep
 	FuzzModeDelay
 
+	// P2PModeValidator is a mode in which a validator only connects to its
+	// sentry nodes (persistent peers) and doesn't take part in peer exchange
+	P2PModeValidator = "validator"
+	// P2PModeSentry is a mode in which a node shields its validator (private
+	// peers) from the rest of the network
+	P2PModeSentry = "sentry"
+
 	// LogFormatPlain is a format for colored text
 	LogFormatPlain = "plain"
 	// LogFormatJSON is a format for json output
@@ -444,6 +454,15 @@ type P2PConfig struct {
 	// Comma separated list of nodes to keep persistent connections to
 	PersistentPeers string `mapstructure:"persistent_peers"`
 
+	// Sentry node architecture role of the node: "validator", "sentry" or
+	// empty for a regular node.
+	//
+	// A validator only accepts its persistent peers (its sentry nodes) and
+	// requires pex and seed mode to be off and no seeds.
+	// A sentry requires pex, seed mode to be off and the validator's ID in
+	// private_peer_ids, so that its address is never gossiped.
+	Mode string `mapstructure:"mode"`
+
 	// UPNP port forwarding
 	UPNP bool `mapstructure:"upnp"`
 
@@ -472,6 +491,23 @@ type P2PConfig struct {
 	// Rate at which packets can be received, in bytes/second
 	RecvRate int64 `mapstructure:"recv_rate"`
 
+	// Rate at which packets can be sent to all peers combined, in bytes/second
+	// 0 means no global limit
+	GlobalSendRate int64 `mapstructure:"global_send_rate"`
+
+	// Rate at which packets can be received from all peers combined, in bytes/second
+	// 0 means no global limit
+	GlobalRecvRate int64 `mapstructure:"global_recv_rate"`
+
+	// Comma separated list of per-channel send rate limits, in bytes/second,
+	// shared by all peers. Each entry has the form "<channel ID>=<rate>"
+	// (e.g. "0x30=512000,0x38=1024000")
+	ChannelSendRates string `mapstructure:"channel_send_rates"`
+
+	// Comma separated list of per-channel receive rate limits, in bytes/second,
+	// shared by all peers. Same format as ChannelSendRates
+	ChannelRecvRates string `mapstructure:"channel_recv_rates"`
+
 	// Set true to enable the peer-exchange reactor
 	PexReactor bool `mapstructure:"pex"`
 
@@ -492,6 +528,12 @@ type P2PConfig struct {
 	HandshakeTimeout time.Duration `mapstructure:"handshake_timeout"`
 	DialTimeout      time.Duration `mapstructure:"dial_timeout"`
 
+	// Proxy used to dial peers, in the form of
+	// "socks5://[user:password@]host:port" (e.g. "socks5://127.0.0.1:9050"
+	// for a local Tor client). Required to dial onion addresses.
+	// Empty means peers are dialed directly
+	DialProxy string `mapstructure:"dial_proxy"`
+
 	// Testing params.
 	// Force dial to fail
 	TestDialFail bool `mapstructure:"test_dial_fail"`
@@ -506,6 +548,7 @@ func DefaultP2PConfig() *P2PConfig {
 		ListenAddress:           "tcp://0.0.0.0:26656",
 		ExternalAddress:         "",
 		UPNP:                    false,
+		Mode:                    "",
 		AddrBook:                defaultAddrBookPath,
 		AddrBookStrict:          true,
 		MaxNumInboundPeers:      40,
@@ -514,11 +557,16 @@ func DefaultP2PConfig() *P2PConfig {
 		MaxPacketMsgPayloadSize: 1024,    // 1 kB
 		SendRate:                5120000, // 5 mB/s
 		RecvRate:                5120000, // 5 mB/s
+		GlobalSendRate:          0,
+		GlobalRecvRate:          0,
+		ChannelSendRates:        "",
+		ChannelRecvRates:        "",
 		PexReactor:              true,
 		SeedMode:                false,
 		AllowDuplicateIP:        false,
 		HandshakeTimeout:        20 * time.Second,
 		DialTimeout:             3 * time.Second,
+		DialProxy:               "",
 		TestDialFail:            false,
 		TestFuzz:                false,
 		TestFuzzConfig:          DefaultFuzzConnConfig(),
@@ -560,9 +608,134 @@ func (cfg *P2PConfig) ValidateBasic() error {
 	if cfg.RecvRate < 0 {
 		return errors.New("recv_rate can't be negative")
 	}
+	if cfg.GlobalSendRate < 0 {
+		return errors.New("global_send_rate can't be negative")
+	}
+	if cfg.GlobalRecvRate < 0 {
+		return errors.New("global_recv_rate can't be negative")
+	}
+	if _, err := cfg.ChannelSendRateLimits(); err != nil {
+		return errors.Wrap(err, "invalid channel_send_rates")
+	}
+	if _, err := cfg.ChannelRecvRateLimits(); err != nil {
+		return errors.Wrap(err, "invalid channel_recv_rates")
+	}
+	if cfg.DialTimeout < 0 {
+		return errors.New("dial_timeout can't be negative")
+	}
+	if err := cfg.validateMode(); err != nil {
+		return err
+	}
+	if cfg.DialProxy != "" {
+		u, err := url.Parse(cfg.DialProxy)
+		if err != nil {
+			return errors.Wrap(err, "invalid dial_proxy")
+		}
+		if u.Scheme != "socks5" || u.Host == "" {
+			return errors.New("dial_proxy must be of the form socks5://[user:password@]host:port")
+		}
+	}
 	return nil
 }
 
+// validateMode checks the configuration is consistent with the sentry node
+// architecture role of the node.
+func (cfg *P2PConfig) validateMode() error {
+	switch cfg.Mode {
+	case "":
+		return nil
+	case P2PModeValidator:
+		if cfg.PexReactor {
+			return errors.New("pex must be disabled in validator mode")
+		}
+		if cfg.SeedMode {
+			return errors.New("seed_mode must be disabled in validator mode")
+		}
+		if strings.TrimSpace(cfg.Seeds) != "" {
+			return errors.New("seeds must be empty in validator mode")
+		}
+		peers := splitAndTrim(cfg.PersistentPeers)
+		if len(peers) == 0 {
+			return errors.New("persistent_peers must list the sentry nodes in validator mode")
+		}
+		for _, peer := range peers {
+			if !strings.Contains(peer, "@") {
+				return fmt.Errorf("persistent peer %q must be of the form ID@host:port in validator mode", peer)
+			}
+		}
+		return nil
+	case P2PModeSentry:
+		if !cfg.PexReactor {
+			return errors.New("pex must be enabled in sentry mode")
+		}
+		if cfg.SeedMode {
+			return errors.New("seed_mode must be disabled in sentry mode")
+		}
+		if len(splitAndTrim(cfg.PrivatePeerIDs)) == 0 {
+			return errors.New("private_peer_ids must list the validator's ID in sentry mode")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q, must be %q, %q or empty", cfg.Mode, P2PModeValidator, P2PModeSentry)
+	}
+}
+
+// ChannelSendRateLimits returns the per-channel send rate limits, keyed by
+// channel ID.
+func (cfg *P2PConfig) ChannelSendRateLimits() (map[byte]int64, error) {
+	return parseChannelRates(cfg.ChannelSendRates)
+}
+
+// ChannelRecvRateLimits returns the per-channel receive rate limits, keyed by
+// channel ID.
+func (cfg *P2PConfig) ChannelRecvRateLimits() (map[byte]int64, error) {
+	return parseChannelRates(cfg.ChannelRecvRates)
+}
+
+// splitAndTrim splits a comma separated list, trimming spaces and dropping
+// empty entries.
+func splitAndTrim(s string) []string {
+	var list []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// parseChannelRates parses a comma separated list of "<channel ID>=<rate>"
+// entries. Channel IDs may be given in decimal or as 0x-prefixed hex.
+func parseChannelRates(s string) (map[byte]int64, error) {
+	rates := make(map[byte]int64)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected <channel ID>=<rate>, got %q", entry)
+		}
+		chID, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel ID %q: %v", parts[0], err)
+		}
+		rate, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %q: %v", parts[1], err)
+		}
+		if rate < 0 {
+			return nil, fmt.Errorf("rate for channel %#x can't be negative", chID)
+		}
+		if _, ok := rates[byte(chID)]; ok {
+			return nil, fmt.Errorf("duplicate channel %#x", chID)
+		}
+		rates[byte(chID)] = rate
+	}
+	return rates, nil
+}
+
 // FuzzConnConfig is a FuzzedConnection configuration.
 type FuzzConnConfig struct {
 	Mode         int
@@ -36,3 +36,58 @@ func TestConfigValidateBasic(t *testing.T) {
 	cfg.Consensus.TimeoutPropose = -10 * time.Second
 	assert.Error(t, cfg.ValidateBasic())
 }
+
+func TestP2PConfigChannelRates(t *testing.T) {
+	cfg := DefaultP2PConfig()
+	cfg.ChannelSendRates = "0x30=512000, 56=1024000"
+	assert.NoError(t, cfg.ValidateBasic())
+
+	rates, err := cfg.ChannelSendRateLimits()
+	assert.NoError(t, err)
+	assert.Equal(t, map[byte]int64{0x30: 512000, 0x38: 1024000}, rates)
+
+	for _, invalid := range []string{"0x30", "0x100=1", "0x30=-1", "0x30=1,0x30=2", "foo=1"} {
+		cfg.ChannelRecvRates = invalid
+		assert.Error(t, cfg.ValidateBasic(), invalid)
+	}
+}
+
+func TestP2PConfigMode(t *testing.T) {
+	validator := func() *P2PConfig {
+		cfg := DefaultP2PConfig()
+		cfg.Mode = P2PModeValidator
+		cfg.PexReactor = false
+		cfg.PersistentPeers = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@sentry-1:26656"
+		return cfg
+	}
+	sentry := func() *P2PConfig {
+		cfg := DefaultP2PConfig()
+		cfg.Mode = P2PModeSentry
+		cfg.PrivatePeerIDs = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+		return cfg
+	}
+
+	assert.NoError(t, validator().ValidateBasic())
+	assert.NoError(t, sentry().ValidateBasic())
+
+	testCases := []struct {
+		name   string
+		cfg    *P2PConfig
+		modify func(*P2PConfig)
+	}{
+		{"unknown mode", DefaultP2PConfig(), func(cfg *P2PConfig) { cfg.Mode = "seed" }},
+		{"validator with pex", validator(), func(cfg *P2PConfig) { cfg.PexReactor = true }},
+		{"validator in seed mode", validator(), func(cfg *P2PConfig) { cfg.SeedMode = true }},
+		{"validator with seeds", validator(), func(cfg *P2PConfig) { cfg.Seeds = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@seed:26656" }},
+		{"validator without sentries", validator(), func(cfg *P2PConfig) { cfg.PersistentPeers = " , " }},
+		{"validator with sentry without ID", validator(), func(cfg *P2PConfig) { cfg.PersistentPeers = "sentry-1:26656" }},
+		{"sentry without pex", sentry(), func(cfg *P2PConfig) { cfg.PexReactor = false }},
+		{"sentry in seed mode", sentry(), func(cfg *P2PConfig) { cfg.SeedMode = true }},
+		{"sentry without private peers", sentry(), func(cfg *P2PConfig) { cfg.PrivatePeerIDs = "" }},
+	}
+
+	for _, tc := range testCases {
+		tc.modify(tc.cfg)
+		assert.Error(t, tc.cfg.ValidateBasic(), tc.name)
+	}
+}
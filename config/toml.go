@@ -210,6 +210,14 @@ seeds = "{{ .P2P.Seeds }}"
 # Comma separated list of nodes to keep persistent connections to
 persistent_peers = "{{ .P2P.PersistentPeers }}"
 
+# Sentry node architecture role of the node: "validator", "sentry" or empty
+# for a regular node.
+# - validator: only the persistent peers (the sentry nodes) may connect;
+#   requires pex and seed_mode to be false and seeds to be empty.
+# - sentry: the validator's address is never gossiped; requires pex to be
+#   true, seed_mode to be false and the validator's ID in private_peer_ids.
+mode = "{{ .P2P.Mode }}"
+
 # UPNP port forwarding
 upnp = {{ .P2P.UPNP }}
 
@@ -238,6 +246,22 @@ send_rate = {{ .P2P.SendRate }}
 # Rate at which packets can be received, in bytes/second
 recv_rate = {{ .P2P.RecvRate }}
 
+# Rate at which packets can be sent to all peers combined, in bytes/second
+# 0 means no global limit
+global_send_rate = {{ .P2P.GlobalSendRate }}
+
+# Rate at which packets can be received from all peers combined, in bytes/second
+# 0 means no global limit
+global_recv_rate = {{ .P2P.GlobalRecvRate }}
+
+# Comma separated list of per-channel send rate limits, in bytes/second,
+# shared by all peers (e.g. "0x30=512000,0x38=1024000")
+channel_send_rates = "{{ .P2P.ChannelSendRates }}"
+
+# Comma separated list of per-channel receive rate limits, in bytes/second,
+# shared by all peers (e.g. "0x30=512000,0x38=1024000")
+channel_recv_rates = "{{ .P2P.ChannelRecvRates }}"
+
 # Set true to enable the peer-exchange reactor
 pex = {{ .P2P.PexReactor }}
 
@@ -257,6 +281,11 @@ allow_duplicate_ip = {{ .P2P.AllowDuplicateIP }}
 handshake_timeout = "{{ .P2P.HandshakeTimeout }}"
 dial_timeout = "{{ .P2P.DialTimeout }}"
 
+# Proxy used to dial peers, in the form of "socks5://[user:password@]host:port"
+# (e.g. "socks5://127.0.0.1:9050" for a local Tor client).
+# Required to dial onion addresses. Leave empty to dial peers directly
+dial_proxy = "{{ .P2P.DialProxy }}"
+
 ##### mempool configuration options #####
 [mempool]
 
@@ -216,6 +216,7 @@ func (conR *ConsensusReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 	}
 
 	conR.Logger.Debug("Receive", "src", src, "chId", chID, "msg", msg)
+	p2p.ObserveMessageReceived(src, chID, msg, len(msgBytes))
 
 	// Get peer states
 	ps, ok := src.Get(types.PeerStateKey).(*PeerState)
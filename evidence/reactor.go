@@ -82,6 +82,7 @@ func (evR *EvidenceReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 	}
 
 	evR.Logger.Debug("Receive", "src", src, "chId", chID, "msg", msg)
+	p2p.ObserveMessageReceived(src, chID, msg, len(msgBytes))
 
 	switch msg := msg.(type) {
 	case *EvidenceListMessage:
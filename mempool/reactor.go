@@ -163,6 +163,7 @@ func (memR *MempoolReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 		return
 	}
 	memR.Logger.Debug("Receive", "src", src, "chId", chID, "msg", msg)
+	p2p.ObserveMessageReceived(src, chID, msg, len(msgBytes))
 
 	switch msg := msg.(type) {
 	case *TxMessage:
@@ -404,12 +404,17 @@ func NewNode(config *cfg.Config,
 	}
 
 	// Setup Transport.
+	bandwidthLimiter, err := p2p.NewBandwidthLimiter(config.P2P, p2pMetrics)
+	if err != nil {
+		return nil, err
+	}
 	var (
 		mConnConfig = p2p.MConnConfig(config.P2P)
-		transport   = p2p.NewMultiplexTransport(nodeInfo, *nodeKey, mConnConfig)
 		connFilters = []p2p.ConnFilterFunc{}
 		peerFilters = []p2p.PeerFilterFunc{}
 	)
+	mConnConfig.Limiter = bandwidthLimiter
+	transport := p2p.NewMultiplexTransport(nodeInfo, *nodeKey, mConnConfig)
 
 	if !config.P2P.AllowDuplicateIP {
 		connFilters = append(connFilters, p2p.ConnDuplicateIPFilter())
@@ -455,8 +460,39 @@ func NewNode(config *cfg.Config,
 		)
 	}
 
+	// In validator mode, only the sentry nodes (persistent peers) are allowed
+	// to connect.
+	if config.P2P.Mode == cfg.P2PModeValidator {
+		sentryIDs := make(map[p2p.ID]struct{})
+		for _, peer := range splitAndTrimEmpty(config.P2P.PersistentPeers, ",", " ") {
+			addr, err := p2p.NewNetAddressString(peer)
+			if err != nil {
+				return nil, err
+			}
+			sentryIDs[addr.ID] = struct{}{}
+		}
+
+		peerFilters = append(
+			peerFilters,
+			func(_ p2p.IPeerSet, p p2p.Peer) error {
+				if _, ok := sentryIDs[p.ID()]; !ok {
+					return fmt.Errorf("peer %v is not one of the validator's sentry nodes", p.ID())
+				}
+
+				return nil
+			},
+		)
+	}
+
 	p2p.MultiplexTransportConnFilters(connFilters...)(transport)
 
+	dialer, err := p2p.NewDialer(config.P2P.DialProxy)
+	if err != nil {
+		return nil, err
+	}
+	p2p.MultiplexTransportDialer(dialer)(transport)
+	p2p.MultiplexTransportDialTimeout(config.P2P.DialTimeout)(transport)
+
 	// Setup Switch.
 	sw := p2p.NewSwitch(
 		config.P2P,
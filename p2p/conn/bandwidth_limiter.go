@@ -0,0 +1,275 @@
+package conn
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+const (
+	// default size of a token bucket, relative to its rate
+	defaultBurstDuration = 100 * time.Millisecond
+
+	// minimum size of a token bucket, so that a full packet always fits
+	minBurst = 64 * 1024
+)
+
+// BandwidthLimiter limits the bandwidth used by all MConnections sharing it.
+//
+// There is one global token bucket per direction (send and receive) and,
+// optionally, one token bucket per channel per direction. When several
+// connections are waiting for the same bucket, packets from channels with
+// higher priority are served first.
+//
+// A nil *BandwidthLimiter does not limit anything.
+type BandwidthLimiter struct {
+	send   *tokenBucket
+	recv   *tokenBucket
+	chSend map[byte]*tokenBucket
+	chRecv map[byte]*tokenBucket
+
+	throttled metrics.Counter
+}
+
+// BandwidthLimiterOption sets an optional parameter on the BandwidthLimiter.
+type BandwidthLimiterOption func(*BandwidthLimiter)
+
+// BandwidthLimiterThrottleMetric sets the counter used to record the time (in
+// seconds) packets were held back by the limiter. The counter is labeled with
+// "direction" and "ch_id".
+func BandwidthLimiterThrottleMetric(c metrics.Counter) BandwidthLimiterOption {
+	return func(bl *BandwidthLimiter) { bl.throttled = c }
+}
+
+// NewBandwidthLimiter returns a new BandwidthLimiter. Rates are in
+// bytes/second; a rate of 0 means no limit.
+func NewBandwidthLimiter(
+	sendRate, recvRate int64,
+	chSendRates, chRecvRates map[byte]int64,
+	options ...BandwidthLimiterOption,
+) *BandwidthLimiter {
+	bl := &BandwidthLimiter{
+		send:      newTokenBucketWithRate(sendRate),
+		recv:      newTokenBucketWithRate(recvRate),
+		chSend:    make(map[byte]*tokenBucket),
+		chRecv:    make(map[byte]*tokenBucket),
+		throttled: discard.NewCounter(),
+	}
+	for chID, rate := range chSendRates {
+		if tb := newTokenBucketWithRate(rate); tb != nil {
+			bl.chSend[chID] = tb
+		}
+	}
+	for chID, rate := range chRecvRates {
+		if tb := newTokenBucketWithRate(rate); tb != nil {
+			bl.chRecv[chID] = tb
+		}
+	}
+	for _, option := range options {
+		option(bl)
+	}
+	return bl
+}
+
+// waitSend blocks until n bytes may be sent on the given channel or quit is
+// closed.
+func (bl *BandwidthLimiter) waitSend(chID byte, priority int, n int, quit <-chan struct{}) {
+	if bl == nil {
+		return
+	}
+	bl.wait("send", chID, priority, n, bl.chSend[chID], bl.send, quit)
+}
+
+// waitRecv blocks until n bytes may be received on the given channel or quit
+// is closed.
+func (bl *BandwidthLimiter) waitRecv(chID byte, priority int, n int, quit <-chan struct{}) {
+	if bl == nil {
+		return
+	}
+	bl.wait("recv", chID, priority, n, bl.chRecv[chID], bl.recv, quit)
+}
+
+func (bl *BandwidthLimiter) wait(
+	direction string,
+	chID byte,
+	priority int,
+	n int,
+	chBucket, globalBucket *tokenBucket,
+	quit <-chan struct{},
+) {
+	start := time.Now()
+	if chBucket != nil && !chBucket.wait(n, priority, quit) {
+		return
+	}
+	if globalBucket != nil && !globalBucket.wait(n, priority, quit) {
+		return
+	}
+	if d := time.Since(start); d > time.Millisecond {
+		bl.throttled.With("direction", direction, "ch_id", fmt.Sprintf("%#x", chID)).Add(d.Seconds())
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// tokenBucket is a token bucket whose waiters are served in order of
+// priority (highest first), then in order of arrival.
+//
+// The bucket is allowed to go into debt: a waiter is served as soon as the
+// bucket holds a positive number of tokens, even if it asks for more. This
+// way packets bigger than the bucket still go through and the rate evens out
+// over time.
+type tokenBucket struct {
+	mtx sync.Mutex
+
+	rate   float64 // tokens (bytes) per second
+	burst  float64 // maximum number of tokens
+	tokens float64
+	last   time.Time
+
+	waiters     waiterQueue
+	seq         uint64
+	dispatching bool
+}
+
+type bucketWaiter struct {
+	n         float64
+	priority  int
+	seq       uint64
+	ready     chan struct{}
+	cancelled bool
+}
+
+// newTokenBucketWithRate returns a full token bucket with the given rate and
+// the default burst, or nil if the rate is not positive.
+func newTokenBucketWithRate(rate int64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := int64(float64(rate) * defaultBurstDuration.Seconds())
+	if burst < minBurst {
+		burst = minBurst
+	}
+	return newTokenBucket(rate, burst)
+}
+
+func newTokenBucket(rate, burst int64) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until n tokens have been taken from the bucket or quit is
+// closed. It returns false if quit was closed first.
+func (tb *tokenBucket) wait(n int, priority int, quit <-chan struct{}) bool {
+	tb.mtx.Lock()
+	tb.refill(time.Now())
+	if len(tb.waiters) == 0 && tb.tokens > 0 {
+		tb.tokens -= float64(n)
+		tb.mtx.Unlock()
+		return true
+	}
+
+	w := &bucketWaiter{
+		n:        float64(n),
+		priority: priority,
+		seq:      tb.seq,
+		ready:    make(chan struct{}),
+	}
+	tb.seq++
+	heap.Push(&tb.waiters, w)
+	if !tb.dispatching {
+		tb.dispatching = true
+		go tb.dispatchRoutine()
+	}
+	tb.mtx.Unlock()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-quit:
+		tb.mtx.Lock()
+		defer tb.mtx.Unlock()
+		select {
+		case <-w.ready:
+			// tokens were taken concurrently
+			return true
+		default:
+		}
+		w.cancelled = true
+		return false
+	}
+}
+
+// dispatchRoutine hands out tokens to waiters as they become available. It
+// exits once there are no more waiters.
+func (tb *tokenBucket) dispatchRoutine() {
+	for {
+		tb.mtx.Lock()
+		tb.refill(time.Now())
+		for len(tb.waiters) > 0 && tb.tokens > 0 {
+			w := heap.Pop(&tb.waiters).(*bucketWaiter)
+			if w.cancelled {
+				continue
+			}
+			tb.tokens -= w.n
+			close(w.ready)
+		}
+		if len(tb.waiters) == 0 {
+			tb.dispatching = false
+			tb.mtx.Unlock()
+			return
+		}
+		// sleep until the bucket holds a positive number of tokens again
+		sleep := time.Duration(-tb.tokens/tb.rate*float64(time.Second)) + time.Millisecond
+		tb.mtx.Unlock()
+
+		time.Sleep(sleep)
+	}
+}
+
+// refill adds tokens accumulated since the last refill.
+// Not goroutine-safe.
+func (tb *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(tb.last)
+	if elapsed <= 0 {
+		return
+	}
+	tb.last = now
+	tb.tokens += elapsed.Seconds() * tb.rate
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+}
+
+// waiterQueue implements heap.Interface, ordering waiters by priority (highest
+// first), then by arrival.
+type waiterQueue []*bucketWaiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *waiterQueue) Push(x interface{}) { *q = append(*q, x.(*bucketWaiter)) }
+
+func (q *waiterQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,117 @@
+package conn
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketEnforcesRate(t *testing.T) {
+	// 10kB/s, with a 1kB burst
+	tb := newTokenBucket(10000, 1000)
+	quit := make(chan struct{})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.True(t, tb.wait(1000, 1, quit))
+	}
+	// the first kB is served from the burst, the remaining 3kB take ~300ms
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 200*time.Millisecond, "expected to be throttled, took %v", elapsed)
+	assert.True(t, elapsed < 2*time.Second, "throttled for too long: %v", elapsed)
+}
+
+func TestTokenBucketServesHigherPriorityFirst(t *testing.T) {
+	tb := newTokenBucket(10000, 1000)
+	quit := make(chan struct{})
+
+	// drain the bucket, so the next waiters have to queue
+	require.True(t, tb.wait(2000, 1, quit))
+
+	served := make(chan int, 2)
+	go func() {
+		tb.wait(1000, 1, quit)
+		served <- 1
+	}()
+	// make sure the low priority waiter is queued first
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		tb.wait(1000, 5, quit)
+		served <- 5
+	}()
+
+	assert.Equal(t, 5, <-served)
+	assert.Equal(t, 1, <-served)
+}
+
+func TestTokenBucketQuit(t *testing.T) {
+	tb := newTokenBucket(1, 1)
+	quit := make(chan struct{})
+
+	require.True(t, tb.wait(1000, 1, quit))
+
+	done := make(chan bool)
+	go func() {
+		done <- tb.wait(1000, 1, quit)
+	}()
+	close(quit)
+
+	select {
+	case ok := <-done:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("expected wait to return after quit was closed")
+	}
+}
+
+func TestMConnectionSharedBandwidthLimiter(t *testing.T) {
+	// 64kB burst, then 64kB/s shared by both connections
+	limiter := NewBandwidthLimiter(64*1024, 0, nil, nil)
+
+	newConn := func() (*MConnection, net.Conn) {
+		server, client := net.Pipe()
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				if _, err := server.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+		cfg := DefaultMConnConfig()
+		cfg.SendRate = 0
+		cfg.Limiter = limiter
+		chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 100}}
+		mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(interface{}) {}, cfg)
+		require.NoError(t, mconn.Start())
+		return mconn, server
+	}
+
+	mconn1, server1 := newConn()
+	defer server1.Close() // nolint: errcheck
+	defer mconn1.Stop()
+	mconn2, server2 := newConn()
+	defer server2.Close() // nolint: errcheck
+	defer mconn2.Stop()
+
+	// 2 x 48kB > burst, so it takes at least (96kB-64kB) / 64kB/s = 0.5s
+	msg := make([]byte, 1024)
+	start := time.Now()
+	for i := 0; i < 48; i++ {
+		require.True(t, mconn1.Send(0x01, msg))
+		require.True(t, mconn2.Send(0x01, msg))
+	}
+	timeout := time.After(5 * time.Second)
+	for mconn1.Status().Channels[0].SendQueueSize > 0 || mconn2.Status().Channels[0].SendQueueSize > 0 {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for the send queues to drain")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 300*time.Millisecond, "expected connections to be throttled, took %v", elapsed)
+}
@@ -124,6 +124,10 @@ type MConnConfig struct {
 
 	// Maximum wait time for pongs
 	PongTimeout time.Duration `mapstructure:"pong_timeout"`
+
+	// Limiter shared by all connections to enforce global and per-channel
+	// bandwidth limits (nil means no limits other than SendRate/RecvRate)
+	Limiter *BandwidthLimiter `mapstructure:"-"`
 }
 
 // DefaultMConnConfig returns the default config.
@@ -514,6 +518,8 @@ func (c *MConnection) sendPacketMsg() bool {
 		return true
 	}
 	c.sendMonitor.Update(int(_n))
+	// Block until the shared limiter allows us to proceed.
+	c.config.Limiter.waitSend(leastChannel.desc.ID, leastChannel.desc.Priority, int(_n), c.Quit())
 	c.flushTimer.Set()
 	return false
 }
@@ -585,6 +591,9 @@ FOR_LOOP:
 				break FOR_LOOP
 			}
 
+			// Block until the shared limiter allows us to proceed.
+			c.config.Limiter.waitRecv(pkt.ChannelID, channel.desc.Priority, int(_n), c.Quit())
+
 			msgBytes, err := channel.recvPacketMsg(pkt)
 			if err != nil {
 				if c.IsRunning() {
@@ -644,6 +653,14 @@ type ChannelStatus struct {
 	SendQueueSize     int
 	Priority          int
 	RecentlySent      int64
+
+	// Totals since the connection was created. Bytes are message bytes
+	// (excluding packet overhead).
+	SendBytes      int64
+	SendMessages   int64
+	SendQueueDrops int64
+	RecvBytes      int64
+	RecvMessages   int64
 }
 
 func (c *MConnection) Status() ConnectionStatus {
@@ -659,6 +676,11 @@ func (c *MConnection) Status() ConnectionStatus {
 			SendQueueSize:     int(atomic.LoadInt32(&channel.sendQueueSize)),
 			Priority:          channel.desc.Priority,
 			RecentlySent:      atomic.LoadInt64(&channel.recentlySent),
+			SendBytes:         atomic.LoadInt64(&channel.sendBytesTotal),
+			SendMessages:      atomic.LoadInt64(&channel.sendMessagesTotal),
+			SendQueueDrops:    atomic.LoadInt64(&channel.sendQueueDropsTotal),
+			RecvBytes:         atomic.LoadInt64(&channel.recvBytesTotal),
+			RecvMessages:      atomic.LoadInt64(&channel.recvMessagesTotal),
 		}
 	}
 	return status
@@ -699,6 +721,13 @@ type Channel struct {
 	sending       []byte
 	recentlySent  int64 // exponential moving average
 
+	// totals, atomic.
+	sendBytesTotal      int64
+	sendMessagesTotal   int64
+	sendQueueDropsTotal int64
+	recvBytesTotal      int64
+	recvMessagesTotal   int64
+
 	maxPacketMsgPayloadSize int
 
 	Logger log.Logger
@@ -729,8 +758,10 @@ func (ch *Channel) sendBytes(bytes []byte) bool {
 	select {
 	case ch.sendQueue <- bytes:
 		atomic.AddInt32(&ch.sendQueueSize, 1)
+		ch.incrSent(bytes)
 		return true
 	case <-time.After(defaultSendTimeout):
+		atomic.AddInt64(&ch.sendQueueDropsTotal, 1)
 		return false
 	}
 }
@@ -742,12 +773,20 @@ func (ch *Channel) trySendBytes(bytes []byte) bool {
 	select {
 	case ch.sendQueue <- bytes:
 		atomic.AddInt32(&ch.sendQueueSize, 1)
+		ch.incrSent(bytes)
 		return true
 	default:
+		atomic.AddInt64(&ch.sendQueueDropsTotal, 1)
 		return false
 	}
 }
 
+// Goroutine-safe
+func (ch *Channel) incrSent(bytes []byte) {
+	atomic.AddInt64(&ch.sendMessagesTotal, 1)
+	atomic.AddInt64(&ch.sendBytesTotal, int64(len(bytes)))
+}
+
 // Goroutine-safe
 func (ch *Channel) loadSendQueueSize() (size int) {
 	return int(atomic.LoadInt32(&ch.sendQueueSize))
@@ -811,6 +850,8 @@ func (ch *Channel) recvPacketMsg(packet PacketMsg) ([]byte, error) {
 	ch.recving = append(ch.recving, packet.Bytes...)
 	if packet.EOF == byte(0x01) {
 		msgBytes := ch.recving
+		atomic.AddInt64(&ch.recvMessagesTotal, 1)
+		atomic.AddInt64(&ch.recvBytesTotal, int64(len(msgBytes)))
 
 		// clear the slice without re-allocating.
 		// http://stackoverflow.com/questions/16971741/how-do-you-clear-a-slice-in-go
@@ -138,6 +138,13 @@ func TestMConnectionReceive(t *testing.T) {
 	case <-time.After(500 * time.Millisecond):
 		t.Fatalf("Did not receive %s message in 500ms", msg)
 	}
+
+	sendStatus := mconn2.Status().Channels[0]
+	assert.EqualValues(t, 1, sendStatus.SendMessages)
+	assert.EqualValues(t, len(msg), sendStatus.SendBytes)
+	recvStatus := mconn1.Status().Channels[0]
+	assert.EqualValues(t, 1, recvStatus.RecvMessages)
+	assert.EqualValues(t, len(msg), recvStatus.RecvBytes)
 }
 
 func TestMConnectionStatus(t *testing.T) {
@@ -526,4 +533,8 @@ func TestMConnectionTrySend(t *testing.T) {
 	assert.False(t, mconn.CanSend(0x01))
 	assert.False(t, mconn.TrySend(0x01, msg))
 	assert.Equal(t, "TrySend", <-resultCh)
+
+	status := mconn.Status().Channels[0]
+	assert.EqualValues(t, 2, status.SendMessages)
+	assert.EqualValues(t, 2, status.SendQueueDrops)
 }
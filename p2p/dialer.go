@@ -0,0 +1,253 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Dialer opens the raw connections used for outbound peers.
+type Dialer interface {
+	Dial(addr *NetAddress, timeout time.Duration) (net.Conn, error)
+}
+
+// NewDialer returns the Dialer for the given proxy URL. An empty URL returns
+// a Dialer connecting directly to peers. The only supported proxy is
+// "socks5://[user:password@]host:port".
+func NewDialer(proxyURL string) (Dialer, error) {
+	if proxyURL == "" {
+		return directDialer{}, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+	if u.Scheme != "socks5" {
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return nil, fmt.Errorf("invalid proxy address: %v", err)
+	}
+
+	d := &socks5Dialer{proxyAddr: u.Host}
+	if u.User != nil {
+		d.username = u.User.Username()
+		d.password, _ = u.User.Password()
+		if len(d.username) > 255 || len(d.password) > 255 {
+			return nil, errors.New("proxy username and password must be at most 255 bytes")
+		}
+	}
+	return d, nil
+}
+
+// directDialer connects directly to peers.
+type directDialer struct{}
+
+func (directDialer) Dial(addr *NetAddress, timeout time.Duration) (net.Conn, error) {
+	return addr.DialTimeout(timeout)
+}
+
+//-----------------------------------------------------------------------------
+
+// ProxyAddr is the remote address of a connection made through a proxy. It
+// reports the dialed address rather than the one of the proxy, so that
+// connections to different peers through the same proxy can be told apart.
+type ProxyAddr struct {
+	Target *NetAddress // dialed peer
+	Proxy  net.Addr    // address of the proxy
+}
+
+var _ net.Addr = (*ProxyAddr)(nil)
+
+// Network implements net.Addr.
+func (pa *ProxyAddr) Network() string { return "tcp" }
+
+// String implements net.Addr.
+func (pa *ProxyAddr) String() string { return pa.Target.DialString() }
+
+// proxiedConn is a connection made through a proxy.
+type proxiedConn struct {
+	net.Conn
+	remoteAddr *ProxyAddr
+}
+
+// RemoteAddr implements net.Conn.
+func (pc *proxiedConn) RemoteAddr() net.Addr { return pc.remoteAddr }
+
+//-----------------------------------------------------------------------------
+
+// SOCKS5 protocol constants, see RFC 1928 and RFC 1929.
+const (
+	socks5Version = 0x05
+
+	socks5AuthNone         = 0x00
+	socks5AuthPassword     = 0x02
+	socks5AuthNoAcceptable = 0xff
+
+	socks5AuthPasswordVersion = 0x01
+
+	socks5CmdConnect = 0x01
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+)
+
+var socks5Replies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socks5Dialer connects to peers through a SOCKS5 proxy (e.g. Tor). Onion
+// addresses are passed to the proxy as domain names, so they are never
+// resolved locally.
+type socks5Dialer struct {
+	proxyAddr string
+	username  string
+	password  string
+}
+
+func (d *socks5Dialer) Dial(addr *NetAddress, timeout time.Duration) (net.Conn, error) {
+	c, err := net.DialTimeout("tcp", d.proxyAddr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout > 0 {
+		if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	if err := d.connect(c, addr); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("socks5 proxy %s: %v", d.proxyAddr, err)
+	}
+	if err := c.SetDeadline(time.Time{}); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return &proxiedConn{
+		Conn:       c,
+		remoteAddr: &ProxyAddr{Target: addr, Proxy: c.RemoteAddr()},
+	}, nil
+}
+
+// connect authenticates with the proxy and asks it to connect to addr.
+func (d *socks5Dialer) connect(c net.Conn, addr *NetAddress) error {
+	// Method selection.
+	methods := []byte{socks5AuthNone}
+	if d.username != "" {
+		methods = []byte{socks5AuthPassword}
+	}
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err := c.Write(greeting); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(c, reply); err != nil {
+		return err
+	}
+	if reply[0] != socks5Version {
+		return fmt.Errorf("unexpected protocol version %d", reply[0])
+	}
+	switch reply[1] {
+	case socks5AuthNone:
+	case socks5AuthPassword:
+		if d.username == "" {
+			return errors.New("proxy requires a username and password")
+		}
+		if err := d.authenticate(c); err != nil {
+			return err
+		}
+	case socks5AuthNoAcceptable:
+		return errors.New("no acceptable authentication method")
+	default:
+		return fmt.Errorf("unsupported authentication method %d", reply[1])
+	}
+
+	// Connect request.
+	req := []byte{socks5Version, socks5CmdConnect, 0x00}
+	switch {
+	case addr.Name != "":
+		req = append(req, socks5AddrDomain, byte(len(addr.Name)))
+		req = append(req, addr.Name...)
+	case addr.IP.To4() != nil:
+		req = append(req, socks5AddrIPv4)
+		req = append(req, addr.IP.To4()...)
+	case addr.IP.To16() != nil:
+		req = append(req, socks5AddrIPv6)
+		req = append(req, addr.IP.To16()...)
+	default:
+		return fmt.Errorf("invalid address %v", addr)
+	}
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, addr.Port)
+	req = append(req, port...)
+	if _, err := c.Write(req); err != nil {
+		return err
+	}
+
+	// Reply: version, status, reserved, bound address and port.
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(c, header); err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return fmt.Errorf("unexpected protocol version %d", header[0])
+	}
+	if header[1] != 0x00 {
+		if msg, ok := socks5Replies[header[1]]; ok {
+			return fmt.Errorf("connect to %v failed: %s", addr, msg)
+		}
+		return fmt.Errorf("connect to %v failed: unknown error %d", addr, header[1])
+	}
+	var addrLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		addrLen = net.IPv4len
+	case socks5AddrIPv6:
+		addrLen = net.IPv6len
+	case socks5AddrDomain:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(c, l); err != nil {
+			return err
+		}
+		addrLen = int(l[0])
+	default:
+		return fmt.Errorf("unexpected address type %d", header[3])
+	}
+	_, err := io.ReadFull(c, make([]byte, addrLen+2))
+	return err
+}
+
+// authenticate performs the username/password authentication of RFC 1929.
+func (d *socks5Dialer) authenticate(c net.Conn) error {
+	req := []byte{socks5AuthPasswordVersion, byte(len(d.username))}
+	req = append(req, d.username...)
+	req = append(req, byte(len(d.password)))
+	req = append(req, d.password...)
+	if _, err := c.Write(req); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(c, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0x00 {
+		return fmt.Errorf("authentication failed, status %d", reply[1])
+	}
+	return nil
+}
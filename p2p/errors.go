@@ -173,6 +173,16 @@ func (e ErrNetAddressLookup) Error() string {
 	return fmt.Sprintf("error looking up host (%s): %v", e.Addr, e.Err)
 }
 
+// ErrNetAddressNoProxy indicates that the address (e.g. an onion address) can
+// only be dialed through a proxy.
+type ErrNetAddressNoProxy struct {
+	Addr string
+}
+
+func (e ErrNetAddressNoProxy) Error() string {
+	return fmt.Sprintf("address (%s) can only be dialed through a proxy", e.Addr)
+}
+
 // ErrCurrentlyDialingOrExistingAddress indicates that we're currently
 // dialing this address or it belongs to an existing peer.
 type ErrCurrentlyDialingOrExistingAddress struct {
@@ -1,6 +1,9 @@
 package p2p
 
 import (
+	"fmt"
+	"reflect"
+
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-kit/kit/metrics/discard"
 	"github.com/go-kit/kit/metrics/prometheus"
@@ -25,6 +28,34 @@ type Metrics struct {
 	PeerPendingSendBytes metrics.Gauge
 	// Number of transactions submitted by each peer.
 	NumTxs metrics.Gauge
+	// Configured bandwidth limit (bytes/second) for a given direction and
+	// channel ("all" for the global limit).
+	BandwidthLimit metrics.Gauge
+	// Time (seconds) packets were held back by the bandwidth limiter.
+	BandwidthThrottleSeconds metrics.Counter
+
+	// Number of message bytes sent to a given peer on a given channel.
+	ChannelSendBytesTotal metrics.Counter
+	// Number of messages sent to a given peer on a given channel.
+	ChannelSendMessagesTotal metrics.Counter
+	// Histogram of message sizes sent to a given peer on a given channel.
+	ChannelSendMessageSizeBytes metrics.Histogram
+	// Number of messages dropped because the send queue of a given peer and
+	// channel was full.
+	ChannelSendQueueDropsTotal metrics.Counter
+	// Number of message bytes received from a given peer on a given channel.
+	ChannelReceiveBytesTotal metrics.Counter
+	// Number of messages received from a given peer on a given channel.
+	ChannelReceiveMessagesTotal metrics.Counter
+	// Number of message bytes received from a given peer on a given channel,
+	// by message type.
+	MessageReceiveBytesTotal metrics.Counter
+	// Number of messages received from a given peer on a given channel, by
+	// message type.
+	MessageReceiveTotal metrics.Counter
+	// Histogram of message sizes received from a given peer on a given
+	// channel, by message type.
+	MessageReceiveSizeBytes metrics.Histogram
 }
 
 // PrometheusMetrics returns Metrics build using Prometheus client library.
@@ -66,16 +97,114 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "num_txs",
 			Help:      "Number of transactions submitted by each peer.",
 		}, append(labels, "peer_id")).With(labelsAndValues...),
+		BandwidthLimit: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "bandwidth_limit",
+			Help:      "Configured bandwidth limit (bytes/second) for a given direction and channel.",
+		}, append(labels, "direction", "ch_id")).With(labelsAndValues...),
+		BandwidthThrottleSeconds: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "bandwidth_throttle_seconds",
+			Help:      "Time packets were held back by the bandwidth limiter.",
+		}, append(labels, "direction", "ch_id")).With(labelsAndValues...),
+		ChannelSendBytesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "channel_send_bytes_total",
+			Help:      "Number of message bytes sent to a given peer on a given channel.",
+		}, append(labels, "peer_id", "ch_id")).With(labelsAndValues...),
+		ChannelSendMessagesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "channel_send_messages_total",
+			Help:      "Number of messages sent to a given peer on a given channel.",
+		}, append(labels, "peer_id", "ch_id")).With(labelsAndValues...),
+		ChannelSendMessageSizeBytes: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "channel_send_message_size_bytes",
+			Help:      "Message sizes sent to a given peer on a given channel.",
+			Buckets:   stdprometheus.ExponentialBuckets(16, 4, 9),
+		}, append(labels, "peer_id", "ch_id")).With(labelsAndValues...),
+		ChannelSendQueueDropsTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "channel_send_queue_drops_total",
+			Help:      "Number of messages dropped because the send queue of a given peer and channel was full.",
+		}, append(labels, "peer_id", "ch_id")).With(labelsAndValues...),
+		ChannelReceiveBytesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "channel_receive_bytes_total",
+			Help:      "Number of message bytes received from a given peer on a given channel.",
+		}, append(labels, "peer_id", "ch_id")).With(labelsAndValues...),
+		ChannelReceiveMessagesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "channel_receive_messages_total",
+			Help:      "Number of messages received from a given peer on a given channel.",
+		}, append(labels, "peer_id", "ch_id")).With(labelsAndValues...),
+		MessageReceiveBytesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "message_receive_bytes_total",
+			Help:      "Number of message bytes received from a given peer on a given channel, by message type.",
+		}, append(labels, "peer_id", "ch_id", "message_type")).With(labelsAndValues...),
+		MessageReceiveTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "message_receive_total",
+			Help:      "Number of messages received from a given peer on a given channel, by message type.",
+		}, append(labels, "peer_id", "ch_id", "message_type")).With(labelsAndValues...),
+		MessageReceiveSizeBytes: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "message_receive_size_bytes",
+			Help:      "Message sizes received from a given peer on a given channel, by message type.",
+			Buckets:   stdprometheus.ExponentialBuckets(16, 4, 9),
+		}, append(labels, "peer_id", "ch_id", "message_type")).With(labelsAndValues...),
 	}
 }
 
 // NopMetrics returns no-op Metrics.
 func NopMetrics() *Metrics {
 	return &Metrics{
-		Peers:                 discard.NewGauge(),
-		PeerReceiveBytesTotal: discard.NewCounter(),
-		PeerSendBytesTotal:    discard.NewCounter(),
-		PeerPendingSendBytes:  discard.NewGauge(),
-		NumTxs:                discard.NewGauge(),
+		Peers:                    discard.NewGauge(),
+		PeerReceiveBytesTotal:    discard.NewCounter(),
+		PeerSendBytesTotal:       discard.NewCounter(),
+		PeerPendingSendBytes:     discard.NewGauge(),
+		NumTxs:                   discard.NewGauge(),
+		BandwidthLimit:           discard.NewGauge(),
+		BandwidthThrottleSeconds: discard.NewCounter(),
+
+		ChannelSendBytesTotal:       discard.NewCounter(),
+		ChannelSendMessagesTotal:    discard.NewCounter(),
+		ChannelSendMessageSizeBytes: discard.NewHistogram(),
+		ChannelSendQueueDropsTotal:  discard.NewCounter(),
+		ChannelReceiveBytesTotal:    discard.NewCounter(),
+		ChannelReceiveMessagesTotal: discard.NewCounter(),
+		MessageReceiveBytesTotal:    discard.NewCounter(),
+		MessageReceiveTotal:         discard.NewCounter(),
+		MessageReceiveSizeBytes:     discard.NewHistogram(),
+	}
+}
+
+// chIDLabel returns the metric label of a channel ID.
+func chIDLabel(chID byte) string {
+	return fmt.Sprintf("%#x", chID)
+}
+
+// messageTypeLabel returns the metric label of a decoded reactor message,
+// e.g. "consensus.VoteMessage".
+func messageTypeLabel(msg interface{}) string {
+	t := reflect.TypeOf(msg)
+	if t == nil {
+		return "nil"
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+	return t.String()
 }
@@ -0,0 +1,20 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testReactorMessage struct{}
+
+func TestMessageTypeLabel(t *testing.T) {
+	assert.Equal(t, "p2p.testReactorMessage", messageTypeLabel(&testReactorMessage{}))
+	assert.Equal(t, "p2p.testReactorMessage", messageTypeLabel(testReactorMessage{}))
+	assert.Equal(t, "nil", messageTypeLabel(nil))
+}
+
+func TestChIDLabel(t *testing.T) {
+	assert.Equal(t, "0x30", chIDLabel(0x30))
+	assert.Equal(t, "0x0", chIDLabel(0x00))
+}
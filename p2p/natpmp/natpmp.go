@@ -0,0 +1,374 @@
+// Package natpmp maps ports on a NAT gateway using the Port Control Protocol
+// (PCP, RFC 6887), falling back to NAT-PMP (RFC 6886) for gateways that don't
+// support it.
+package natpmp
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort is the port PCP and NAT-PMP gateways listen on.
+	DefaultPort = 5351
+
+	defaultInitialTimeout = 250 * time.Millisecond
+	defaultRetries        = 4
+
+	natpmpVersion = 0
+	pcpVersion    = 2
+
+	natpmpOpExternalAddress = 0
+	natpmpOpMapUDP          = 1
+	natpmpOpMapTCP          = 2
+
+	pcpOpAnnounce = 0
+	pcpOpMap      = 1
+
+	responseBit = 0x80
+
+	pcpHeaderSize    = 24
+	pcpMapSize       = 36
+	pcpNonceSize     = 12
+	pcpResultOK      = 0
+	pcpUnsuppVersion = 1
+
+	protocolNumberTCP = 6
+	protocolNumberUDP = 17
+)
+
+// Mapping is a port mapped on the gateway.
+type Mapping struct {
+	Protocol     string // "tcp" or "udp"
+	InternalPort int
+	ExternalIP   net.IP
+	ExternalPort int
+	Lifetime     time.Duration
+}
+
+// ResultError is returned when the gateway refuses a request.
+type ResultError struct {
+	Protocol string // "pcp" or "nat-pmp"
+	Code     int
+}
+
+func (e ResultError) Error() string {
+	return fmt.Sprintf("%s gateway returned result code %d", e.Protocol, e.Code)
+}
+
+// Client requests port mappings from a gateway. The protocol (PCP or NAT-PMP)
+// is detected on the first request.
+type Client struct {
+	mtx sync.Mutex
+
+	gateway        *net.UDPAddr
+	initialTimeout time.Duration
+	retries        int
+
+	detected bool
+	pcp      bool
+
+	// The nonce identifies our PCP mappings, so that they can be renewed.
+	nonce [pcpNonceSize]byte
+}
+
+// ClientOption sets an optional parameter on the Client.
+type ClientOption func(*Client)
+
+// ClientRetransmission sets the time waited for the first response, and the
+// number of times a request is sent. The time waited doubles on each retry.
+func ClientRetransmission(initialTimeout time.Duration, retries int) ClientOption {
+	return func(c *Client) {
+		c.initialTimeout = initialTimeout
+		c.retries = retries
+	}
+}
+
+// NewClient returns a client for the gateway, given as "host" or "host:port".
+// The port defaults to DefaultPort.
+func NewClient(gateway string, options ...ClientOption) (*Client, error) {
+	if _, _, err := net.SplitHostPort(gateway); err != nil {
+		gateway = net.JoinHostPort(gateway, strconv.Itoa(DefaultPort))
+	}
+	addr, err := net.ResolveUDPAddr("udp", gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		gateway:        addr,
+		initialTimeout: defaultInitialTimeout,
+		retries:        defaultRetries,
+	}
+	if _, err := rand.Read(c.nonce[:]); err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// DefaultGateway guesses the gateway of the local network as the first
+// address of the /24 of the local IPv4 address used to reach the internet.
+// Gateways using another address must be configured explicitly.
+func DefaultGateway() (net.IP, error) {
+	// No packet is sent, this only selects the route.
+	conn, err := net.Dial("udp4", "198.51.100.1:9")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() // nolint: errcheck
+
+	ip := conn.LocalAddr().(*net.UDPAddr).IP.To4()
+	if ip == nil {
+		return nil, errors.New("no local IPv4 address")
+	}
+	gateway := make(net.IP, net.IPv4len)
+	copy(gateway, ip)
+	gateway[3] = 1
+	return gateway, nil
+}
+
+// Protocol returns the protocol spoken by the gateway, "pcp" or "nat-pmp", or
+// an empty string if it is not known yet.
+func (c *Client) Protocol() string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.detected {
+		return ""
+	}
+	return c.protocol()
+}
+
+// MapPort asks the gateway to forward the external port (or another one, if
+// it is not available) to the internal port, for the given lifetime.
+func (c *Client) MapPort(protocol string, internalPort, externalPort int, lifetime time.Duration) (Mapping, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if err := c.detect(); err != nil {
+		return Mapping{}, err
+	}
+	if c.pcp {
+		return c.pcpMap(protocol, internalPort, externalPort, lifetime)
+	}
+	return c.natpmpMap(protocol, internalPort, externalPort, lifetime)
+}
+
+// UnmapPort deletes the mapping of the internal port.
+func (c *Client) UnmapPort(protocol string, internalPort int) error {
+	_, err := c.MapPort(protocol, internalPort, 0, 0)
+	return err
+}
+
+func (c *Client) protocol() string {
+	if c.pcp {
+		return "pcp"
+	}
+	return "nat-pmp"
+}
+
+// detect sends a PCP ANNOUNCE request. NAT-PMP gateways reply with version 0.
+func (c *Client) detect() error {
+	if c.detected {
+		return nil
+	}
+
+	req := c.pcpRequest(pcpOpAnnounce, 0, nil)
+	resp, err := c.roundTrip(req, func(resp []byte) bool {
+		if len(resp) >= 2 && resp[0] == natpmpVersion {
+			return true
+		}
+		return len(resp) >= pcpHeaderSize && resp[0] == pcpVersion && resp[1] == responseBit|pcpOpAnnounce
+	})
+	if err != nil {
+		return err
+	}
+
+	c.pcp = resp[0] == pcpVersion && resp[3] != pcpUnsuppVersion
+	c.detected = true
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// PCP
+
+// pcpRequest returns a PCP request header followed by the opcode payload. The
+// client IP is filled in by roundTrip.
+func (c *Client) pcpRequest(op byte, lifetime time.Duration, payload []byte) []byte {
+	req := make([]byte, pcpHeaderSize+len(payload))
+	req[0] = pcpVersion
+	req[1] = op
+	binary.BigEndian.PutUint32(req[4:8], uint32(lifetime/time.Second))
+	copy(req[pcpHeaderSize:], payload)
+	return req
+}
+
+func (c *Client) pcpMap(protocol string, internalPort, externalPort int, lifetime time.Duration) (Mapping, error) {
+	protoNum, err := protocolNumber(protocol)
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	payload := make([]byte, pcpMapSize)
+	copy(payload[0:12], c.nonce[:])
+	payload[12] = protoNum
+	binary.BigEndian.PutUint16(payload[16:18], uint16(internalPort))
+	binary.BigEndian.PutUint16(payload[18:20], uint16(externalPort))
+	// suggest any IPv4 external address (::ffff:0.0.0.0)
+	copy(payload[20:36], net.IPv4zero.To16())
+	req := c.pcpRequest(pcpOpMap, lifetime, payload)
+
+	resp, err := c.roundTrip(req, func(resp []byte) bool {
+		if len(resp) < pcpHeaderSize || resp[0] != pcpVersion || resp[1] != responseBit|pcpOpMap {
+			return false
+		}
+		if resp[3] != pcpResultOK {
+			return true
+		}
+		return len(resp) >= pcpHeaderSize+pcpMapSize &&
+			string(resp[24:36]) == string(c.nonce[:]) &&
+			resp[36] == protoNum &&
+			int(binary.BigEndian.Uint16(resp[40:42])) == internalPort
+	})
+	if err != nil {
+		return Mapping{}, err
+	}
+	if resp[3] != pcpResultOK {
+		return Mapping{}, ResultError{"pcp", int(resp[3])}
+	}
+
+	externalIP := net.IP(append([]byte(nil), resp[44:60]...))
+	if ip4 := externalIP.To4(); ip4 != nil {
+		externalIP = ip4
+	}
+
+	return Mapping{
+		Protocol:     protocol,
+		InternalPort: internalPort,
+		ExternalIP:   externalIP,
+		ExternalPort: int(binary.BigEndian.Uint16(resp[42:44])),
+		Lifetime:     time.Duration(binary.BigEndian.Uint32(resp[4:8])) * time.Second,
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
+// NAT-PMP
+
+func (c *Client) natpmpExternalAddress() (net.IP, error) {
+	req := []byte{natpmpVersion, natpmpOpExternalAddress}
+	resp, err := c.roundTrip(req, func(resp []byte) bool {
+		return len(resp) >= 12 && resp[0] == natpmpVersion && resp[1] == responseBit|natpmpOpExternalAddress
+	})
+	if err != nil {
+		return nil, err
+	}
+	if code := binary.BigEndian.Uint16(resp[2:4]); code != 0 {
+		return nil, ResultError{"nat-pmp", int(code)}
+	}
+	return net.IP(append([]byte(nil), resp[8:12]...)), nil
+}
+
+func (c *Client) natpmpMap(protocol string, internalPort, externalPort int, lifetime time.Duration) (Mapping, error) {
+	var op byte
+	switch protocol {
+	case "tcp":
+		op = natpmpOpMapTCP
+	case "udp":
+		op = natpmpOpMapUDP
+	default:
+		return Mapping{}, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+
+	req := make([]byte, 12)
+	req[0] = natpmpVersion
+	req[1] = op
+	binary.BigEndian.PutUint16(req[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(req[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+
+	resp, err := c.roundTrip(req, func(resp []byte) bool {
+		return len(resp) >= 16 && resp[0] == natpmpVersion && resp[1] == responseBit|op &&
+			int(binary.BigEndian.Uint16(resp[8:10])) == internalPort
+	})
+	if err != nil {
+		return Mapping{}, err
+	}
+	if code := binary.BigEndian.Uint16(resp[2:4]); code != 0 {
+		return Mapping{}, ResultError{"nat-pmp", int(code)}
+	}
+
+	m := Mapping{
+		Protocol:     protocol,
+		InternalPort: internalPort,
+		ExternalPort: int(binary.BigEndian.Uint16(resp[10:12])),
+		Lifetime:     time.Duration(binary.BigEndian.Uint32(resp[12:16])) * time.Second,
+	}
+	if lifetime > 0 {
+		if m.ExternalIP, err = c.natpmpExternalAddress(); err != nil {
+			return Mapping{}, err
+		}
+	}
+	return m, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// roundTrip sends the request until a response accepted by match is
+// received. The PCP client IP of the request is filled in, if present.
+// Not goroutine-safe.
+func (c *Client) roundTrip(req []byte, match func([]byte) bool) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, c.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() // nolint: errcheck
+
+	if req[0] == pcpVersion {
+		copy(req[8:24], conn.LocalAddr().(*net.UDPAddr).IP.To16())
+	}
+
+	buf := make([]byte, 1100) // maximum size of a PCP message
+	timeout := c.initialTimeout
+	for i := 0; i < c.retries; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			if match(buf[:n]) {
+				return append([]byte(nil), buf[:n]...), nil
+			}
+		}
+		timeout *= 2
+	}
+	return nil, fmt.Errorf("no response from gateway %v", c.gateway)
+}
+
+func protocolNumber(protocol string) (byte, error) {
+	switch protocol {
+	case "tcp":
+		return protocolNumberTCP, nil
+	case "udp":
+		return protocolNumberUDP, nil
+	default:
+		return 0, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
@@ -0,0 +1,253 @@
+package natpmp
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPCP(t *testing.T) {
+	gw := newFakeGateway(t, true)
+	defer gw.Close() // nolint: errcheck
+
+	c := gw.client(t)
+	assert.Equal(t, "", c.Protocol())
+
+	m, err := c.MapPort("tcp", 26656, 26656, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "pcp", c.Protocol())
+	assert.Equal(t, "tcp", m.Protocol)
+	assert.Equal(t, 26656, m.InternalPort)
+	assert.Equal(t, 26656, m.ExternalPort)
+	assert.Equal(t, "203.0.113.7", m.ExternalIP.String())
+	assert.Equal(t, time.Hour, m.Lifetime)
+	assert.Equal(t, 1, gw.numMappings())
+
+	// renewing keeps the same mapping
+	_, err = c.MapPort("tcp", 26656, m.ExternalPort, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, gw.numMappings())
+
+	require.NoError(t, c.UnmapPort("tcp", 26656))
+	assert.Equal(t, 0, gw.numMappings())
+}
+
+func TestClientNATPMP(t *testing.T) {
+	gw := newFakeGateway(t, false)
+	defer gw.Close() // nolint: errcheck
+
+	c := gw.client(t)
+
+	m, err := c.MapPort("tcp", 26656, 26656, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "nat-pmp", c.Protocol())
+	assert.Equal(t, 26656, m.ExternalPort)
+	assert.Equal(t, "203.0.113.7", m.ExternalIP.String())
+	assert.Equal(t, time.Hour, m.Lifetime)
+	assert.Equal(t, 1, gw.numMappings())
+
+	require.NoError(t, c.UnmapPort("tcp", 26656))
+	assert.Equal(t, 0, gw.numMappings())
+}
+
+func TestClientExternalPortTaken(t *testing.T) {
+	for _, pcp := range []bool{true, false} {
+		gw := newFakeGateway(t, pcp)
+		gw.takePort(26656)
+
+		m, err := gw.client(t).MapPort("tcp", 26656, 26656, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 26657, m.ExternalPort)
+
+		gw.Close() // nolint: errcheck
+	}
+}
+
+func TestClientResultError(t *testing.T) {
+	for _, pcp := range []bool{true, false} {
+		gw := newFakeGateway(t, pcp)
+		gw.setRefuse(true)
+
+		_, err := gw.client(t).MapPort("tcp", 26656, 26656, time.Hour)
+		assert.IsType(t, ResultError{}, err)
+
+		gw.Close() // nolint: errcheck
+	}
+}
+
+func TestClientNoGateway(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close() // nolint: errcheck
+
+	c, err := NewClient(conn.LocalAddr().String(), ClientRetransmission(10*time.Millisecond, 2))
+	require.NoError(t, err)
+
+	_, err = c.MapPort("tcp", 26656, 26656, time.Hour)
+	assert.Error(t, err)
+}
+
+//-----------------------------------------------------------------------------
+
+// fakeGateway is an in-process PCP or NAT-PMP gateway. NAT-PMP gateways
+// answer PCP requests with an "unsupported version" error, as per RFC 6887.
+type fakeGateway struct {
+	conn *net.UDPConn
+	pcp  bool
+
+	mtx      sync.Mutex
+	mappings map[int]int // internal port -> external port
+	taken    map[int]bool
+	refuse   bool
+}
+
+var fakeExternalIP = net.IPv4(203, 0, 113, 7).To4()
+
+func newFakeGateway(t *testing.T, pcp bool) *fakeGateway {
+	addr, err := net.ResolveUDPAddr("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	conn, err := net.ListenUDP("udp", addr)
+	require.NoError(t, err)
+
+	gw := &fakeGateway{
+		conn:     conn,
+		pcp:      pcp,
+		mappings: make(map[int]int),
+		taken:    make(map[int]bool),
+	}
+	go gw.serve()
+	return gw
+}
+
+func (gw *fakeGateway) Close() error {
+	return gw.conn.Close()
+}
+
+func (gw *fakeGateway) client(t *testing.T) *Client {
+	c, err := NewClient(gw.conn.LocalAddr().String(), ClientRetransmission(50*time.Millisecond, 3))
+	require.NoError(t, err)
+	return c
+}
+
+func (gw *fakeGateway) takePort(port int) {
+	gw.mtx.Lock()
+	defer gw.mtx.Unlock()
+	gw.taken[port] = true
+}
+
+func (gw *fakeGateway) setRefuse(refuse bool) {
+	gw.mtx.Lock()
+	defer gw.mtx.Unlock()
+	gw.refuse = refuse
+}
+
+func (gw *fakeGateway) numMappings() int {
+	gw.mtx.Lock()
+	defer gw.mtx.Unlock()
+	return len(gw.mappings)
+}
+
+func (gw *fakeGateway) serve() {
+	buf := make([]byte, 1100)
+	for {
+		n, from, err := gw.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := buf[:n]
+
+		var resp []byte
+		switch {
+		case req[0] == pcpVersion && gw.pcp:
+			resp = gw.handlePCP(req)
+		case req[0] == pcpVersion:
+			// unsupported version, in NAT-PMP format
+			resp = make([]byte, 8)
+			resp[1] = responseBit | req[1]
+			binary.BigEndian.PutUint16(resp[2:4], 1)
+		case req[0] == natpmpVersion && !gw.pcp:
+			resp = gw.handleNATPMP(req)
+		default:
+			continue
+		}
+		_, _ = gw.conn.WriteToUDP(resp, from)
+	}
+}
+
+// mapPort maps the internal port, or deletes the mapping if lifetime is 0.
+func (gw *fakeGateway) mapPort(internalPort, externalPort int, lifetime uint32) (int, bool) {
+	gw.mtx.Lock()
+	defer gw.mtx.Unlock()
+
+	if gw.refuse {
+		return 0, false
+	}
+	if lifetime == 0 {
+		delete(gw.mappings, internalPort)
+		return 0, true
+	}
+	if ext, ok := gw.mappings[internalPort]; ok {
+		return ext, true
+	}
+	for gw.taken[externalPort] {
+		externalPort++
+	}
+	gw.taken[externalPort] = true
+	gw.mappings[internalPort] = externalPort
+	return externalPort, true
+}
+
+func (gw *fakeGateway) handlePCP(req []byte) []byte {
+	resp := make([]byte, len(req))
+	copy(resp, req)
+	resp[1] = responseBit | req[1]
+	resp[2] = 0
+	resp[3] = pcpResultOK
+	for i := 8; i < pcpHeaderSize; i++ {
+		resp[i] = 0
+	}
+
+	if req[1] == pcpOpMap {
+		lifetime := binary.BigEndian.Uint32(req[4:8])
+		internalPort := int(binary.BigEndian.Uint16(req[40:42]))
+		externalPort := int(binary.BigEndian.Uint16(req[42:44]))
+		ext, ok := gw.mapPort(internalPort, externalPort, lifetime)
+		if !ok {
+			resp[3] = 2 // NOT_AUTHORIZED
+			return resp
+		}
+		binary.BigEndian.PutUint16(resp[42:44], uint16(ext))
+		copy(resp[44:60], fakeExternalIP.To16())
+	}
+	return resp
+}
+
+func (gw *fakeGateway) handleNATPMP(req []byte) []byte {
+	switch req[1] {
+	case natpmpOpExternalAddress:
+		resp := make([]byte, 12)
+		resp[1] = responseBit | natpmpOpExternalAddress
+		copy(resp[8:12], fakeExternalIP)
+		return resp
+	default:
+		resp := make([]byte, 16)
+		resp[1] = responseBit | req[1]
+		lifetime := binary.BigEndian.Uint32(req[8:12])
+		internalPort := int(binary.BigEndian.Uint16(req[4:6]))
+		externalPort := int(binary.BigEndian.Uint16(req[6:8]))
+		ext, ok := gw.mapPort(internalPort, externalPort, lifetime)
+		if !ok {
+			binary.BigEndian.PutUint16(resp[2:4], 2) // not authorized
+			return resp
+		}
+		copy(resp[8:10], req[4:6])
+		binary.BigEndian.PutUint16(resp[10:12], uint16(ext))
+		copy(resp[12:16], req[8:12])
+		return resp
+	}
+}
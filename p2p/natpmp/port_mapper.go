@@ -0,0 +1,153 @@
+package natpmp
+
+import (
+	"sync"
+	"time"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+)
+
+const (
+	defaultLifetime      = time.Hour
+	defaultRetryInterval = time.Minute
+)
+
+// PortMapper keeps a port mapped on the gateway, renewing the mapping before
+// it expires. The mapping is deleted when the PortMapper is stopped.
+type PortMapper struct {
+	cmn.BaseService
+
+	client        *Client
+	protocol      string
+	internalPort  int
+	lifetime      time.Duration
+	retryInterval time.Duration
+	onMapped      func(Mapping)
+
+	mtx     sync.Mutex
+	mapping *Mapping
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// PortMapperOption sets an optional parameter on the PortMapper.
+type PortMapperOption func(*PortMapper)
+
+// PortMapperLifetime sets the lifetime requested for the mapping. The
+// mapping is renewed after half of the lifetime granted by the gateway.
+func PortMapperLifetime(lifetime time.Duration) PortMapperOption {
+	return func(pm *PortMapper) { pm.lifetime = lifetime }
+}
+
+// PortMapperRetryInterval sets the time waited before retrying after a
+// failed request.
+func PortMapperRetryInterval(interval time.Duration) PortMapperOption {
+	return func(pm *PortMapper) { pm.retryInterval = interval }
+}
+
+// PortMapperOnMapped sets a callback, called whenever the external address
+// or port of the mapping changes.
+func PortMapperOnMapped(f func(Mapping)) PortMapperOption {
+	return func(pm *PortMapper) { pm.onMapped = f }
+}
+
+// NewPortMapper returns a new PortMapper for the internal port. It asks for
+// the same external port.
+func NewPortMapper(client *Client, protocol string, internalPort int, options ...PortMapperOption) *PortMapper {
+	pm := &PortMapper{
+		client:        client,
+		protocol:      protocol,
+		internalPort:  internalPort,
+		lifetime:      defaultLifetime,
+		retryInterval: defaultRetryInterval,
+		onMapped:      func(Mapping) {},
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	pm.BaseService = *cmn.NewBaseService(nil, "PortMapper", pm)
+	for _, option := range options {
+		option(pm)
+	}
+	return pm
+}
+
+// OnStart implements cmn.Service.
+func (pm *PortMapper) OnStart() error {
+	go pm.mapRoutine()
+	return nil
+}
+
+// OnStop implements cmn.Service by deleting the mapping.
+func (pm *PortMapper) OnStop() {
+	close(pm.quit)
+	<-pm.done
+
+	if _, ok := pm.Mapping(); !ok {
+		return
+	}
+	if err := pm.client.UnmapPort(pm.protocol, pm.internalPort); err != nil {
+		pm.Logger.Error("Failed to delete port mapping", "err", err)
+	}
+}
+
+// Mapping returns the current mapping, if any.
+func (pm *PortMapper) Mapping() (Mapping, bool) {
+	pm.mtx.Lock()
+	defer pm.mtx.Unlock()
+
+	if pm.mapping == nil {
+		return Mapping{}, false
+	}
+	return *pm.mapping, true
+}
+
+func (pm *PortMapper) mapRoutine() {
+	defer close(pm.done)
+
+	for {
+		wait := pm.retryInterval
+		if m, err := pm.mapPort(); err != nil {
+			pm.Logger.Error("Failed to map port", "port", pm.internalPort, "err", err)
+		} else if m.Lifetime > 0 {
+			wait = m.Lifetime / 2
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-pm.quit:
+			return
+		}
+	}
+}
+
+// mapPort requests or renews the mapping.
+func (pm *PortMapper) mapPort() (Mapping, error) {
+	externalPort := pm.internalPort
+	if prev, ok := pm.Mapping(); ok {
+		externalPort = prev.ExternalPort
+	}
+
+	m, err := pm.client.MapPort(pm.protocol, pm.internalPort, externalPort, pm.lifetime)
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	pm.mtx.Lock()
+	changed := pm.mapping == nil ||
+		!pm.mapping.ExternalIP.Equal(m.ExternalIP) ||
+		pm.mapping.ExternalPort != m.ExternalPort
+	pm.mapping = &m
+	pm.mtx.Unlock()
+
+	if changed {
+		pm.Logger.Info("Mapped port",
+			"protocol", pm.client.Protocol(),
+			"internal_port", pm.internalPort,
+			"external_ip", m.ExternalIP,
+			"external_port", m.ExternalPort,
+			"lifetime", m.Lifetime)
+		pm.onMapped(m)
+	}
+	return m, nil
+}
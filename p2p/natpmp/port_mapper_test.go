@@ -0,0 +1,73 @@
+package natpmp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestPortMapperRenewsAndDeletesMapping(t *testing.T) {
+	gw := newFakeGateway(t, true)
+	defer gw.Close() // nolint: errcheck
+
+	mapped := make(chan Mapping, 10)
+	pm := NewPortMapper(gw.client(t), "tcp", 26656,
+		// 1s lifetime, so that the mapping is renewed every 500ms
+		PortMapperLifetime(time.Second),
+		PortMapperOnMapped(func(m Mapping) { mapped <- m }),
+	)
+	pm.SetLogger(log.TestingLogger())
+	require.NoError(t, pm.Start())
+
+	select {
+	case m := <-mapped:
+		assert.Equal(t, 26656, m.ExternalPort)
+		assert.Equal(t, "203.0.113.7", m.ExternalIP.String())
+	case <-time.After(time.Second):
+		t.Fatal("expected port to be mapped")
+	}
+
+	// the mapping is renewed, but doesn't change
+	time.Sleep(1200 * time.Millisecond)
+	assert.Len(t, mapped, 0)
+	assert.Equal(t, 1, gw.numMappings())
+
+	m, ok := pm.Mapping()
+	require.True(t, ok)
+	assert.Equal(t, 26656, m.ExternalPort)
+
+	require.NoError(t, pm.Stop())
+	assert.Equal(t, 0, gw.numMappings())
+}
+
+func TestPortMapperRetries(t *testing.T) {
+	gw := newFakeGateway(t, false)
+	defer gw.Close() // nolint: errcheck
+	gw.setRefuse(true)
+
+	mapped := make(chan Mapping, 1)
+	pm := NewPortMapper(gw.client(t), "tcp", 26656,
+		PortMapperRetryInterval(100*time.Millisecond),
+		PortMapperOnMapped(func(m Mapping) { mapped <- m }),
+	)
+	pm.SetLogger(log.TestingLogger())
+	require.NoError(t, pm.Start())
+	defer pm.Stop() // nolint: errcheck
+
+	time.Sleep(200 * time.Millisecond)
+	_, ok := pm.Mapping()
+	assert.False(t, ok)
+
+	gw.setRefuse(false)
+
+	select {
+	case m := <-mapped:
+		assert.Equal(t, 26656, m.ExternalPort)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected port to be mapped after retrying")
+	}
+}
@@ -20,13 +20,14 @@ import (
 
 // NetAddress defines information about a peer on the network
 // including its ID, IP address, and port.
+//
+// Tor onion services have no IP address. Instead, Name holds the onion host
+// (e.g. "expyuzz4wqqyqhjn.onion"), which can only be dialed through a proxy.
 type NetAddress struct {
 	ID   ID     `json:"id"`
 	IP   net.IP `json:"ip"`
 	Port uint16 `json:"port"`
-
-	// TODO:
-	// Name string `json:"name"` // optional DNS name
+	Name string `json:"name,omitempty"` // onion host, if any
 
 	// memoize .String()
 	str string
@@ -41,10 +42,16 @@ func IDAddressString(id ID, protocolHostPort string) string {
 
 // NewNetAddress returns a new NetAddress using the provided TCP
 // address. When testing, other net.Addr (except TCP) will result in
-// using 0.0.0.0:0. When normal run, other net.Addr (except TCP) will
-// panic.
-// TODO: socks proxies?
+// using 0.0.0.0:0. When normal run, other net.Addr (except TCP and
+// ProxyAddr) will panic.
 func NewNetAddress(id ID, addr net.Addr) *NetAddress {
+	if proxyAddr, ok := addr.(*ProxyAddr); ok {
+		na := *proxyAddr.Target
+		na.ID = id
+		na.str = ""
+		return &na
+	}
+
 	tcpAddr, ok := addr.(*net.TCPAddr)
 	if !ok {
 		if flag.Lookup("test.v") == nil { // normal run
@@ -64,7 +71,7 @@ func NewNetAddress(id ID, addr net.Addr) *NetAddress {
 
 // NewNetAddressString returns a new NetAddress using the provided address in
 // the form of "ID@IP:Port".
-// Also resolves the host if host is not an IP.
+// Also resolves the host if host is not an IP nor an onion host.
 // Errors are of type ErrNetAddressXxx where Xxx is in (NoID, Invalid, Lookup)
 func NewNetAddressString(addr string) (*NetAddress, error) {
 	spl := strings.Split(addr, "@")
@@ -76,7 +83,7 @@ func NewNetAddressString(addr string) (*NetAddress, error) {
 
 // NewNetAddressStringWithOptionalID returns a new NetAddress using the
 // provided address in the form of "ID@IP:Port", where the ID is optional.
-// Also resolves the host if host is not an IP.
+// Also resolves the host if host is not an IP nor an onion host.
 func NewNetAddressStringWithOptionalID(addr string) (*NetAddress, error) {
 	addrWithoutProtocol := removeProtocolIfDefined(addr)
 
@@ -107,6 +114,23 @@ func NewNetAddressStringWithOptionalID(addr string) (*NetAddress, error) {
 			errors.New("host is empty")}
 	}
 
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, ErrNetAddressInvalid{portStr, err}
+	}
+
+	// Onion hosts must never be looked up, as that would leak them to the
+	// DNS resolver.
+	if strings.HasSuffix(strings.ToLower(host), onionSuffix) {
+		if !isOnionHost(host) {
+			return nil, ErrNetAddressInvalid{
+				addrWithoutProtocol,
+				errors.New("invalid onion host")}
+		}
+		na := &NetAddress{ID: id, Name: strings.ToLower(host), Port: uint16(port)}
+		return na, nil
+	}
+
 	ip := net.ParseIP(host)
 	if ip == nil {
 		ips, err := net.LookupIP(host)
@@ -116,11 +140,6 @@ func NewNetAddressStringWithOptionalID(addr string) (*NetAddress, error) {
 		ip = ips[0]
 	}
 
-	port, err := strconv.ParseUint(portStr, 10, 16)
-	if err != nil {
-		return nil, ErrNetAddressInvalid{portStr, err}
-	}
-
 	na := NewNetAddressIPPort(ip, uint16(port))
 	na.ID = id
 	return na, nil
@@ -192,14 +211,22 @@ func (na *NetAddress) DialString() string {
 	if na == nil {
 		return "<nil-NetAddress>"
 	}
+	host := na.IP.String()
+	if na.Name != "" {
+		host = na.Name
+	}
 	return net.JoinHostPort(
-		na.IP.String(),
+		host,
 		strconv.FormatUint(uint64(na.Port), 10),
 	)
 }
 
 // Dial calls net.Dial on the address.
+// Onion addresses can't be dialed directly and return ErrNetAddressNoProxy.
 func (na *NetAddress) Dial() (net.Conn, error) {
+	if na.Onion() {
+		return nil, ErrNetAddressNoProxy{na.String()}
+	}
 	conn, err := net.Dial("tcp", na.DialString())
 	if err != nil {
 		return nil, err
@@ -208,7 +235,11 @@ func (na *NetAddress) Dial() (net.Conn, error) {
 }
 
 // DialTimeout calls net.DialTimeout on the address.
+// Onion addresses can't be dialed directly and return ErrNetAddressNoProxy.
 func (na *NetAddress) DialTimeout(timeout time.Duration) (net.Conn, error) {
+	if na.Onion() {
+		return nil, ErrNetAddressNoProxy{na.String()}
+	}
 	conn, err := net.DialTimeout("tcp", na.DialString(), timeout)
 	if err != nil {
 		return nil, err
@@ -232,6 +263,9 @@ func (na *NetAddress) Valid() bool {
 			return false
 		}
 	}
+	if na.Name != "" {
+		return na.Onion()
+	}
 	return na.IP != nil && !(na.IP.IsUnspecified() || na.RFC3849() ||
 		na.IP.Equal(net.IPv4bcast))
 }
@@ -242,6 +276,11 @@ func (na *NetAddress) HasID() bool {
 	return string(na.ID) != ""
 }
 
+// Onion returns true if the address is a Tor onion service.
+func (na *NetAddress) Onion() bool {
+	return isOnionHost(na.Name)
+}
+
 // Local returns true if it is a local address.
 func (na *NetAddress) Local() bool {
 	return na.IP.IsLoopback() || zero4.Contains(na.IP)
@@ -333,6 +372,31 @@ func (na *NetAddress) RFC4862() bool { return rfc4862.Contains(na.IP) }
 func (na *NetAddress) RFC6052() bool { return rfc6052.Contains(na.IP) }
 func (na *NetAddress) RFC6145() bool { return rfc6145.Contains(na.IP) }
 
+// Onion hosts are the base32 encoding of a 10 byte (v2) or 35 byte (v3)
+// identifier, followed by ".onion".
+const (
+	onionSuffix   = ".onion"
+	onionV2Length = 16
+	onionV3Length = 56
+)
+
+func isOnionHost(host string) bool {
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, onionSuffix) {
+		return false
+	}
+	label := strings.TrimSuffix(host, onionSuffix)
+	if len(label) != onionV2Length && len(label) != onionV3Length {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z') && !(r >= '2' && r <= '7') {
+			return false
+		}
+	}
+	return true
+}
+
 func removeProtocolIfDefined(addr string) string {
 	if strings.Contains(addr, "://") {
 		return strings.Split(addr, "://")[1]
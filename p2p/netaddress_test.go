@@ -3,6 +3,7 @@ package p2p
 import (
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,6 +56,13 @@ func TestNewNetAddressStringWithOptionalID(t *testing.T) {
 		{"node id delimiter 1", "@", "", false},
 		{"node id delimiter 2", " @", "", false},
 		{"node id delimiter 3", " @ ", "", false},
+
+		{"onion v2", "expyuzz4wqqyqhjn.onion:26656", "expyuzz4wqqyqhjn.onion:26656", true},
+		{"onion v3", "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion:26656", "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion:26656", true},
+		{"onion uppercase", "EXPYUZZ4WQQYQHJN.onion:26656", "expyuzz4wqqyqhjn.onion:26656", true},
+		{"onion w/ nodeId", "tcp://deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@expyuzz4wqqyqhjn.onion:26656", "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@expyuzz4wqqyqhjn.onion:26656", true},
+		{"onion wrong length", "expyuzz4wqqyqhj.onion:26656", "", false},
+		{"onion invalid chars", "expyuzz4wqqyqhj1.onion:26656", "", false},
 	}
 
 	for _, tc := range testCases {
@@ -129,6 +137,25 @@ func TestNetAddressProperties(t *testing.T) {
 	}
 }
 
+func TestNetAddressOnion(t *testing.T) {
+	addr, err := NewNetAddressString("deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@expyuzz4wqqyqhjn.onion:26656")
+	require.Nil(t, err)
+
+	assert.Nil(t, addr.IP)
+	assert.True(t, addr.Onion())
+	assert.True(t, addr.Valid())
+	assert.False(t, addr.Local())
+	assert.True(t, addr.Routable())
+	assert.Equal(t, "expyuzz4wqqyqhjn.onion:26656", addr.DialString())
+
+	_, err = addr.DialTimeout(time.Second)
+	assert.IsType(t, ErrNetAddressNoProxy{}, err)
+
+	// a name that isn't an onion host is invalid
+	addr.Name = "example.com"
+	assert.False(t, addr.Valid())
+}
+
 func TestNetAddressReachabilityTo(t *testing.T) {
 	// TODO add more test cases
 	testCases := []struct {
@@ -72,12 +72,17 @@ func (pc peerConn) ID() ID {
 	return PubKeyToID(pc.conn.(*tmconn.SecretConnection).RemotePubKey())
 }
 
-// Return the IP from the connection RemoteAddr
+// Return the IP from the connection RemoteAddr. For connections made through
+// a proxy, it is the IP of the dialed address (nil for onion addresses).
 func (pc peerConn) RemoteIP() net.IP {
 	if pc.ip != nil {
 		return pc.ip
 	}
 
+	if proxyAddr, ok := pc.conn.RemoteAddr().(*ProxyAddr); ok {
+		return proxyAddr.Target.IP
+	}
+
 	host, _, err := net.SplitHostPort(pc.conn.RemoteAddr().String())
 	if err != nil {
 		panic(err)
@@ -247,9 +252,7 @@ func (p *peer) Send(chID byte, msgBytes []byte) bool {
 		return false
 	}
 	res := p.mconn.Send(chID, msgBytes)
-	if res {
-		p.metrics.PeerSendBytesTotal.With("peer_id", string(p.ID())).Add(float64(len(msgBytes)))
-	}
+	p.observeSend(chID, msgBytes, res)
 	return res
 }
 
@@ -262,12 +265,25 @@ func (p *peer) TrySend(chID byte, msgBytes []byte) bool {
 		return false
 	}
 	res := p.mconn.TrySend(chID, msgBytes)
-	if res {
-		p.metrics.PeerSendBytesTotal.With("peer_id", string(p.ID())).Add(float64(len(msgBytes)))
-	}
+	p.observeSend(chID, msgBytes, res)
 	return res
 }
 
+// observeSend records the metrics for a message queued (or dropped, if ok is
+// false) on the channel chID.
+func (p *peer) observeSend(chID byte, msgBytes []byte, ok bool) {
+	peerID, chLabel := string(p.ID()), chIDLabel(chID)
+	if !ok {
+		p.metrics.ChannelSendQueueDropsTotal.With("peer_id", peerID, "ch_id", chLabel).Add(1)
+		return
+	}
+	size := float64(len(msgBytes))
+	p.metrics.PeerSendBytesTotal.With("peer_id", peerID).Add(size)
+	p.metrics.ChannelSendBytesTotal.With("peer_id", peerID, "ch_id", chLabel).Add(size)
+	p.metrics.ChannelSendMessagesTotal.With("peer_id", peerID, "ch_id", chLabel).Add(1)
+	p.metrics.ChannelSendMessageSizeBytes.With("peer_id", peerID, "ch_id", chLabel).Observe(size)
+}
+
 // Get the data for a given key.
 func (p *peer) Get(key string) interface{} {
 	return p.Data.Get(key)
@@ -333,6 +349,21 @@ func PeerMetrics(metrics *Metrics) PeerOption {
 	}
 }
 
+// ObserveMessageReceived records the metrics for a message received from src
+// on the channel chID, once a reactor has decoded it into msg. size is the
+// length of the encoded message. It should be called from Reactor#Receive.
+// It does nothing for peers which were not created by the Switch (e.g. mocks).
+func ObserveMessageReceived(src Peer, chID byte, msg interface{}, size int) {
+	p, ok := src.(*peer)
+	if !ok {
+		return
+	}
+	labels := []string{"peer_id", string(p.ID()), "ch_id", chIDLabel(chID), "message_type", messageTypeLabel(msg)}
+	p.metrics.MessageReceiveBytesTotal.With(labels...).Add(float64(size))
+	p.metrics.MessageReceiveTotal.With(labels...).Add(1)
+	p.metrics.MessageReceiveSizeBytes.With(labels...).Observe(float64(size))
+}
+
 func (p *peer) metricsReporter() {
 	for {
 		select {
@@ -369,7 +400,10 @@ func createMConnection(
 			// which does onPeerError.
 			panic(fmt.Sprintf("Unknown channel %X", chID))
 		}
-		p.metrics.PeerReceiveBytesTotal.With("peer_id", string(p.ID())).Add(float64(len(msgBytes)))
+		peerID, chLabel, size := string(p.ID()), chIDLabel(chID), float64(len(msgBytes))
+		p.metrics.PeerReceiveBytesTotal.With("peer_id", peerID).Add(size)
+		p.metrics.ChannelReceiveBytesTotal.With("peer_id", peerID, "ch_id", chLabel).Add(size)
+		p.metrics.ChannelReceiveMessagesTotal.With("peer_id", peerID, "ch_id", chLabel).Add(1)
 		reactor.Receive(chID, p, msgBytes)
 	}
 
@@ -76,6 +76,11 @@ func (ps *PeerSet) HasIP(peerIP net.IP) bool {
 // hasIP does not acquire a lock so it can be used in public methods which
 // already lock.
 func (ps *PeerSet) hasIP(peerIP net.IP) bool {
+	// Peers reached through a proxy (e.g. onion services) have no IP.
+	if peerIP == nil {
+		return false
+	}
+
 	for _, item := range ps.lookup {
 		if item.peer.RemoteIP().Equal(peerIP) {
 			return true
@@ -137,6 +137,12 @@ func (a *addrBook) OnStart() error {
 	}
 	a.loadFromFile(a.filePath)
 
+	// Private peers may have been added to the book before they were made
+	// private. Make sure they are never gossiped.
+	a.mtx.Lock()
+	a.removePrivateAddrs()
+	a.mtx.Unlock()
+
 	// wg.Add to ensure that any invocation of .Wait()
 	// later on will wait for saveRoutine to terminate.
 	a.wg.Add(1)
@@ -178,6 +184,8 @@ func (a *addrBook) OurAddress(addr *p2p.NetAddress) bool {
 	return ok
 }
 
+// AddPrivateIDs implements AddrBook. Known addresses of these peers are
+// removed from the book.
 func (a *addrBook) AddPrivateIDs(IDs []string) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
@@ -185,6 +193,7 @@ func (a *addrBook) AddPrivateIDs(IDs []string) {
 	for _, id := range IDs {
 		a.privateIDs[p2p.ID(id)] = struct{}{}
 	}
+	a.removePrivateAddrs()
 }
 
 // AddAddress implements AddrBook
@@ -566,6 +575,15 @@ func (a *addrBook) removeFromAllBuckets(ka *knownAddress) {
 	delete(a.addrLookup, ka.ID())
 }
 
+// removePrivateAddrs removes the addresses of private peers from the book.
+func (a *addrBook) removePrivateAddrs() {
+	for id := range a.privateIDs {
+		if ka := a.addrLookup[id]; ka != nil {
+			a.removeFromAllBuckets(ka)
+		}
+	}
+}
+
 //----------------------------------------------------------
 
 func (a *addrBook) pickOldest(bucketType byte, bucketIdx int) *knownAddress {
@@ -773,8 +791,9 @@ func (a *addrBook) calcOldBucket(addr *p2p.NetAddress) int {
 
 // Return a string representing the network group of this address.
 // This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
-// "local" for a local address and the string "unroutable" for an unroutable
-// address.
+// "local" for a local address, the string "unroutable" for an unroutable
+// address and "onion:" followed by the first character of the host for an
+// onion address.
 func (a *addrBook) groupKey(na *p2p.NetAddress) string {
 	if a.routabilityStrict && na.Local() {
 		return "local"
@@ -783,6 +802,10 @@ func (a *addrBook) groupKey(na *p2p.NetAddress) string {
 		return "unroutable"
 	}
 
+	if na.Onion() {
+		return "onion:" + na.Name[:1]
+	}
+
 	if ipv4 := na.IP.To4(); ipv4 != nil {
 		return (&net.IPNet{IP: na.IP, Mask: net.CIDRMask(16, 32)}).String()
 	}
@@ -433,6 +433,36 @@ func TestPrivatePeers(t *testing.T) {
 	}
 }
 
+func TestPrivatePeersAreRemovedFromBook(t *testing.T) {
+	fname := createTempFileName("addrbook_test")
+	defer deleteTempFile(fname)
+
+	book := NewAddrBook(fname, true)
+	book.SetLogger(log.TestingLogger())
+
+	addrs, private := testCreatePrivateAddrs(t, 10)
+	for _, addr := range addrs {
+		require.NoError(t, book.AddAddress(addr, addr))
+	}
+	book.MarkGood(addrs[0].ID)
+	book.saveToFile(fname)
+
+	// addresses known before the peers were made private are removed
+	book.AddPrivateIDs(private[:5])
+	assert.Equal(t, 5, book.Size())
+	for _, addr := range addrs[:5] {
+		assert.False(t, book.HasAddress(addr))
+	}
+
+	// including the ones loaded from the file on start
+	book = NewAddrBook(fname, true)
+	book.SetLogger(log.TestingLogger())
+	book.AddPrivateIDs(private)
+	require.NoError(t, book.Start())
+	defer book.Stop() // nolint: errcheck
+	assert.True(t, book.Empty())
+}
+
 func testAddrBookAddressSelection(t *testing.T, bookSize int) {
 	// generate all combinations of old (m) and new addresses
 	for nBookOld := 0; nBookOld <= bookSize; nBookOld++ {
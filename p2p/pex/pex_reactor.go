@@ -214,6 +214,7 @@ func (r *PEXReactor) Receive(chID byte, src Peer, msgBytes []byte) {
 		return
 	}
 	r.Logger.Debug("Received message", "src", src, "chId", chID, "msg", msg)
+	p2p.ObserveMessageReceived(src, chID, msg, len(msgBytes))
 
 	switch msg := msg.(type) {
 	case *pexRequestMessage:
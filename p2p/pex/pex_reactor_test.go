@@ -417,6 +417,68 @@ func TestPEXReactorDoesNotAddPrivatePeersToAddrBook(t *testing.T) {
 	assert.Equal(t, size, book.Size())
 }
 
+// Sentry node architecture: the validator only connects to its sentry, which
+// must never gossip the validator's address, even if it was in its address
+// book before the validator was made private.
+func TestPEXReactorSentryDoesNotGossipValidator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pex_reactor")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	var (
+		books  = make([]*addrBook, 3)
+		public = p2p.CreateRandomPeer(false).SocketAddr()
+	)
+	newSwitch := func(i int, withPEX bool) *p2p.Switch {
+		return p2p.MakeSwitch(cfg, i, "testing", "123.123.123", func(i int, sw *p2p.Switch) *p2p.Switch {
+			books[i] = NewAddrBook(filepath.Join(dir, fmt.Sprintf("addrbook%d.json", i)), false)
+			books[i].SetLogger(log.TestingLogger())
+			sw.SetAddrBook(books[i])
+
+			if withPEX {
+				r := NewPEXReactor(books[i], &PEXReactorConfig{})
+				r.SetLogger(log.TestingLogger())
+				sw.AddReactor("pex", r)
+			}
+			return sw
+		})
+	}
+
+	// validator (pex disabled), sentry and a node from the rest of the network
+	validator := newSwitch(0, false)
+	sentry := newSwitch(1, true)
+	other := newSwitch(2, true)
+
+	// The sentry knows about a public node, and already knew the validator.
+	require.NoError(t, books[1].AddAddress(public, public))
+	require.NoError(t, books[1].AddAddress(validator.NetAddress(), validator.NetAddress()))
+	books[1].AddPrivateIDs([]string{string(validator.NodeInfo().ID())})
+
+	for _, sw := range []*p2p.Switch{validator, sentry, other} {
+		require.NoError(t, sw.Start())
+		defer sw.Stop()
+	}
+
+	// The validator connects to its sentry, then the other node connects to
+	// the sentry and asks for addresses.
+	require.NoError(t, validator.DialPeerWithAddress(sentry.NetAddress(), true))
+	require.NoError(t, books[2].AddAddress(sentry.NetAddress(), sentry.NetAddress()))
+	require.NoError(t, other.DialPeerWithAddress(sentry.NetAddress(), false))
+
+	timeout := time.After(5 * time.Second)
+	for !books[2].HasAddress(public) {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for the sentry to gossip addresses")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	assert.True(t, sentry.Peers().Has(validator.NodeInfo().ID()))
+	assert.False(t, books[1].HasAddress(validator.NetAddress()))
+	assert.False(t, books[2].HasAddress(validator.NetAddress()))
+}
+
 func TestPEXReactorDialPeer(t *testing.T) {
 	pexR, book := createReactor(&PEXReactorConfig{})
 	defer teardownReactor(book)
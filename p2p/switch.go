@@ -40,6 +40,42 @@ func MConnConfig(cfg *config.P2PConfig) conn.MConnConfig {
 	return mConfig
 }
 
+// NewBandwidthLimiter creates a BandwidthLimiter, to be shared by all
+// connections (see MConnConfig.Limiter), from the global and per-channel rates
+// in the P2PConfig. The configured limits are reported to the given metrics.
+// It returns nil if no limits are configured.
+func NewBandwidthLimiter(cfg *config.P2PConfig, metrics *Metrics) (*conn.BandwidthLimiter, error) {
+	chSendRates, err := cfg.ChannelSendRateLimits()
+	if err != nil {
+		return nil, err
+	}
+	chRecvRates, err := cfg.ChannelRecvRateLimits()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.GlobalSendRate == 0 && cfg.GlobalRecvRate == 0 &&
+		len(chSendRates) == 0 && len(chRecvRates) == 0 {
+		return nil, nil
+	}
+
+	metrics.BandwidthLimit.With("direction", "send", "ch_id", "all").Set(float64(cfg.GlobalSendRate))
+	metrics.BandwidthLimit.With("direction", "recv", "ch_id", "all").Set(float64(cfg.GlobalRecvRate))
+	for chID, rate := range chSendRates {
+		metrics.BandwidthLimit.With("direction", "send", "ch_id", chIDLabel(chID)).Set(float64(rate))
+	}
+	for chID, rate := range chRecvRates {
+		metrics.BandwidthLimit.With("direction", "recv", "ch_id", chIDLabel(chID)).Set(float64(rate))
+	}
+
+	return conn.NewBandwidthLimiter(
+		cfg.GlobalSendRate,
+		cfg.GlobalRecvRate,
+		chSendRates,
+		chRecvRates,
+		conn.BandwidthLimiterThrottleMetric(metrics.BandwidthThrottleSeconds),
+	), nil
+}
+
 //-----------------------------------------------------------------------------
 
 // An AddrBook represents an address book from the pex package, which is used
@@ -110,6 +110,18 @@ func MultiplexTransportFilterTimeout(
 	return func(mt *MultiplexTransport) { mt.filterTimeout = timeout }
 }
 
+// MultiplexTransportDialer sets the Dialer used for outbound connections,
+// defaults to dialing peers directly.
+func MultiplexTransportDialer(dialer Dialer) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) { mt.dialer = dialer }
+}
+
+// MultiplexTransportDialTimeout sets the timeout for establishing outbound
+// connections.
+func MultiplexTransportDialTimeout(timeout time.Duration) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) { mt.dialTimeout = timeout }
+}
+
 // MultiplexTransportResolver sets the Resolver used for ip lokkups, defaults to
 // net.DefaultResolver.
 func MultiplexTransportResolver(resolver IPResolver) MultiplexTransportOption {
@@ -129,6 +141,7 @@ type MultiplexTransport struct {
 	conns       ConnSet
 	connFilters []ConnFilterFunc
 
+	dialer           Dialer
 	dialTimeout      time.Duration
 	filterTimeout    time.Duration
 	handshakeTimeout time.Duration
@@ -155,6 +168,7 @@ func NewMultiplexTransport(
 	return &MultiplexTransport{
 		acceptc:          make(chan accept),
 		closec:           make(chan struct{}),
+		dialer:           directDialer{},
 		dialTimeout:      defaultDialTimeout,
 		filterTimeout:    defaultFilterTimeout,
 		handshakeTimeout: defaultHandshakeTimeout,
@@ -194,7 +208,7 @@ func (mt *MultiplexTransport) Dial(
 	addr NetAddress,
 	cfg peerConfig,
 ) (Peer, error) {
-	c, err := addr.DialTimeout(mt.dialTimeout)
+	c, err := mt.dialer.Dial(&addr, mt.dialTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -523,6 +537,15 @@ func upgradeSecretConn(
 }
 
 func resolveIPs(resolver IPResolver, c net.Conn) ([]net.IP, error) {
+	// Connections made through a proxy must not be resolved, as the proxy
+	// would be looked up instead of the peer, and onion hosts would leak.
+	if proxyAddr, ok := c.RemoteAddr().(*ProxyAddr); ok {
+		if proxyAddr.Target.IP == nil {
+			return []net.IP{}, nil
+		}
+		return []net.IP{proxyAddr.Target.IP}, nil
+	}
+
 	host, _, err := net.SplitHostPort(c.RemoteAddr().String())
 	if err != nil {
 		return nil, err
@@ -96,56 +96,96 @@ import (
 //   						"SendQueueCapacity": "1",
 //   						"SendQueueSize": "0",
 //   						"Priority": "5",
-//   						"RecentlySent": "0"
+//   						"RecentlySent": "0",
+//   						"SendBytes": "0",
+//   						"SendMessages": "0",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "0",
+//   						"RecvMessages": "0"
 //   					},
 //   					{
 //   						"ID": 64,
 //   						"SendQueueCapacity": "1000",
 //   						"SendQueueSize": "0",
 //   						"Priority": "10",
-//   						"RecentlySent": "14"
+//   						"RecentlySent": "14",
+//   						"SendBytes": "42",
+//   						"SendMessages": "1",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "42",
+//   						"RecvMessages": "1"
 //   					},
 //   					{
 //   						"ID": 32,
 //   						"SendQueueCapacity": "100",
 //   						"SendQueueSize": "0",
 //   						"Priority": "5",
-//   						"RecentlySent": "619"
+//   						"RecentlySent": "619",
+//   						"SendBytes": "1857",
+//   						"SendMessages": "15",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "1857",
+//   						"RecvMessages": "15"
 //   					},
 //   					{
 //   						"ID": 33,
 //   						"SendQueueCapacity": "100",
 //   						"SendQueueSize": "0",
 //   						"Priority": "10",
-//   						"RecentlySent": "1363"
+//   						"RecentlySent": "1363",
+//   						"SendBytes": "4089",
+//   						"SendMessages": "34",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "4089",
+//   						"RecvMessages": "34"
 //   					},
 //   					{
 //   						"ID": 34,
 //   						"SendQueueCapacity": "100",
 //   						"SendQueueSize": "0",
 //   						"Priority": "5",
-//   						"RecentlySent": "2145"
+//   						"RecentlySent": "2145",
+//   						"SendBytes": "6435",
+//   						"SendMessages": "53",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "6435",
+//   						"RecvMessages": "53"
 //   					},
 //   					{
 //   						"ID": 35,
 //   						"SendQueueCapacity": "2",
 //   						"SendQueueSize": "0",
 //   						"Priority": "1",
-//   						"RecentlySent": "0"
+//   						"RecentlySent": "0",
+//   						"SendBytes": "0",
+//   						"SendMessages": "0",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "0",
+//   						"RecvMessages": "0"
 //   					},
 //   					{
 //   						"ID": 56,
 //   						"SendQueueCapacity": "1",
 //   						"SendQueueSize": "0",
 //   						"Priority": "5",
-//   						"RecentlySent": "0"
+//   						"RecentlySent": "0",
+//   						"SendBytes": "0",
+//   						"SendMessages": "0",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "0",
+//   						"RecvMessages": "0"
 //   					},
 //   					{
 //   						"ID": 0,
 //   						"SendQueueCapacity": "10",
 //   						"SendQueueSize": "0",
 //   						"Priority": "1",
-//   						"RecentlySent": "10"
+//   						"RecentlySent": "10",
+//   						"SendBytes": "30",
+//   						"SendMessages": "1",
+//   						"SendQueueDrops": "0",
+//   						"RecvBytes": "30",
+//   						"RecvMessages": "1"
 //   					}
 //   				]
 //   			},